@@ -0,0 +1,66 @@
+// Package compositor follows the focused window through the IPC sockets of
+// Wayland compositors. Under XWayland, _NET_ACTIVE_WINDOW only knows about
+// X11 windows, so focus moving to a native Wayland window goes unseen.
+package compositor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Window describes the focused window as reported by the compositor.
+// The zero Window means nothing is focused, such as on an empty workspace.
+type Window struct {
+	Class      string // WM_CLASS class name, or the Wayland app_id
+	Instance   string // WM_CLASS instance name, or the Wayland app_id
+	PID        uint32 // Process of the window, or 0 if unknown
+	XID        uint32 // X11 window ID for XWayland windows, or 0
+	Fullscreen bool
+	Area       Rect // Area covered, by the fullscreen container if there is one
+}
+
+// Rect is an area in the compositor's layout coordinates, which XWayland
+// uses for the root window too.
+type Rect struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Client follows the focused window of one compositor.
+type Client interface {
+	// Name names the compositor protocol, for log messages.
+	Name() string
+	// Watch calls fn with the focused window now and again whenever the
+	// focus or its fullscreen state changes. It blocks until the
+	// connection fails.
+	Watch(fn func(Window)) error
+}
+
+// Detect finds the IPC socket of the running compositor from the
+// environment: SWAYSOCK or I3SOCK for sway and i3, and
+// HYPRLAND_INSTANCE_SIGNATURE for Hyprland.
+func Detect() (Client, error) {
+	if path := os.Getenv("SWAYSOCK"); path != "" {
+		return NewI3(path), nil
+	}
+	if path := os.Getenv("I3SOCK"); path != "" {
+		return NewI3(path), nil
+	}
+	if sig := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"); sig != "" {
+		return NewHyprland(hyprlandDir(sig)), nil
+	}
+	return nil, errors.New("no compositor IPC found (SWAYSOCK, I3SOCK and HYPRLAND_INSTANCE_SIGNATURE are unset)")
+}
+
+// hyprlandDir returns the directory holding Hyprland's sockets. Hyprland
+// 0.40 moved it from /tmp/hypr to $XDG_RUNTIME_DIR/hypr.
+func hyprlandDir(sig string) string {
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		dir := filepath.Join(runtime, "hypr", sig)
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return filepath.Join("/tmp/hypr", sig)
+}
@@ -0,0 +1,131 @@
+package compositor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+// Hyprland follows the focus through Hyprland's event socket, and reads the
+// focused window from its request socket.
+type Hyprland struct {
+	dir string
+}
+
+// NewHyprland returns a client for the sockets in dir, which holds
+// .socket.sock for requests and .socket2.sock for events.
+func NewHyprland(dir string) *Hyprland {
+	return &Hyprland{dir: dir}
+}
+
+// Name implements Client.
+func (c *Hyprland) Name() string {
+	return "Hyprland"
+}
+
+// hyprlandEvents are the events after which the focused window is read
+// again. Workspace and monitor changes move the focus too.
+var hyprlandEvents = map[string]bool{
+	"activewindowv2": true,
+	"closewindow":    true,
+	"fullscreen":     true,
+	"workspace":      true,
+	"focusedmon":     true,
+}
+
+// hyprlandWindow is the part of j/activewindow the focus is read from.
+type hyprlandWindow struct {
+	Address string `json:"address"`
+	Class   string `json:"class"`
+	PID     int64  `json:"pid"`
+	At      [2]int `json:"at"`
+	Size    [2]int `json:"size"`
+	// Fullscreen is a boolean up to Hyprland 0.41, and the fullscreen mode
+	// after: 0 for none, 1 for maximized, 2 for fullscreen, 3 for both.
+	Fullscreen json.RawMessage `json:"fullscreen"`
+}
+
+// Watch implements Client.
+func (c *Hyprland) Watch(fn func(Window)) error {
+	path := filepath.Join(c.dir, ".socket2.sock")
+	events, err := net.Dial("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", path, err)
+	}
+	defer events.Close()
+
+	last, err := c.focused()
+	if err != nil {
+		return err
+	}
+	fn(last)
+
+	// Events are lines of the form "name>>data".
+	scanner := bufio.NewScanner(events)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessage)
+	for scanner.Scan() {
+		name, _, _ := strings.Cut(scanner.Text(), ">>")
+		if !hyprlandEvents[name] {
+			continue
+		}
+
+		w, err := c.focused()
+		if err != nil {
+			return err
+		}
+		if w != last {
+			last = w
+			fn(w)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read Hyprland events: %w", err)
+	}
+	return errors.New("Hyprland closed the event socket")
+}
+
+// focused asks for the focused window. Hyprland answers one request per
+// connection.
+func (c *Hyprland) focused() (Window, error) {
+	path := filepath.Join(c.dir, ".socket.sock")
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return Window{}, fmt.Errorf("failed to connect to %s: %w", path, err)
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, "j/activewindow"); err != nil {
+		return Window{}, fmt.Errorf("failed to query Hyprland: %w", err)
+	}
+	payload, err := io.ReadAll(io.LimitReader(conn, maxMessage))
+	if err != nil {
+		return Window{}, fmt.Errorf("failed to query Hyprland: %w", err)
+	}
+
+	var hw hyprlandWindow
+	if err := json.Unmarshal(payload, &hw); err != nil {
+		return Window{}, fmt.Errorf("invalid Hyprland window: %w", err)
+	}
+	if hw.Address == "" {
+		return Window{}, nil
+	}
+
+	w := Window{
+		Class:    hw.Class,
+		Instance: hw.Class,
+		Area:     Rect{X: hw.At[0], Y: hw.At[1], Width: hw.Size[0], Height: hw.Size[1]},
+	}
+	if hw.PID > 0 {
+		w.PID = uint32(hw.PID)
+	}
+	var mode int
+	if json.Unmarshal(hw.Fullscreen, &w.Fullscreen) != nil && json.Unmarshal(hw.Fullscreen, &mode) == nil {
+		w.Fullscreen = mode&2 != 0
+	}
+	return w, nil
+}
@@ -0,0 +1,94 @@
+package compositor
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// serveHyprland starts a fake Hyprland with its two sockets in a new
+// directory. Each request is answered with the next of windows, and after
+// each but the last an ignored and a focus event are sent before the event
+// socket is closed.
+func serveHyprland(t *testing.T, windows []string) string {
+	t.Helper()
+	dir := t.TempDir()
+	requests, err := net.Listen("unix", filepath.Join(dir, ".socket.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { requests.Close() })
+	events, err := net.Listen("unix", filepath.Join(dir, ".socket2.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { events.Close() })
+
+	served := make(chan int)
+	go func() {
+		for i, w := range windows {
+			conn, err := requests.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 64)
+			n, _ := conn.Read(buf)
+			if string(buf[:n]) != "j/activewindow" {
+				t.Errorf("request = %q, want j/activewindow", buf[:n])
+			}
+			io.WriteString(conn, w)
+			conn.Close()
+			served <- i
+		}
+	}()
+
+	go func() {
+		conn, err := events.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for i := range served {
+			if i+1 == len(windows) {
+				return
+			}
+			io.WriteString(conn, "openlayer>>notifications\nactivewindowv2>>5678\n")
+		}
+	}()
+	return dir
+}
+
+func TestHyprlandWatchFocus(t *testing.T) {
+	dir := serveHyprland(t, []string{
+		// Up to 0.41, fullscreen is a boolean.
+		`{"address":"0x1234","class":"cs2","pid":42,"at":[0,0],"size":[1920,1080],"fullscreen":true}`,
+		// After, it is a mode: 1 is maximized, 2 fullscreen.
+		`{"address":"0x1234","class":"cs2","pid":42,"at":[0,0],"size":[1920,1080],"fullscreen":1}`,
+		`{"address":"0x1234","class":"cs2","pid":42,"at":[0,0],"size":[1920,1080],"fullscreen":2}`,
+		`{"address":"0x1234","class":"cs2","pid":42,"at":[0,0],"size":[1920,1080],"fullscreen":false}`,
+		`{"address":"0x1234","class":"cs2","pid":42,"at":[0,0],"size":[1920,1080],"fullscreen":false}`,
+		// An empty workspace has no active window.
+		`{}`,
+	})
+
+	got, err := watchAll(t, NewHyprland(dir))
+	if err == nil {
+		t.Fatal("Watch returned without an error after the socket closed")
+	}
+
+	cs2 := Window{Class: "cs2", Instance: "cs2", PID: 42, Area: Rect{Width: 1920, Height: 1080}}
+	fullscreen := cs2
+	fullscreen.Fullscreen = true
+	want := []Window{fullscreen, cs2, fullscreen, cs2, {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Watch reported\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestHyprlandWatchNoSocket(t *testing.T) {
+	if _, err := watchAll(t, NewHyprland(t.TempDir())); err == nil {
+		t.Fatal("Watch succeeded without a Hyprland socket")
+	}
+}
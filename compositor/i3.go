@@ -0,0 +1,208 @@
+package compositor
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+)
+
+// i3 IPC message and event types. Events have the high bit set.
+const (
+	i3Subscribe      = 2
+	i3GetTree        = 4
+	i3EventWorkspace = 0x80000000
+	i3EventWindow    = 0x80000003
+)
+
+// i3Magic starts every i3 IPC message.
+const i3Magic = "i3-ipc"
+
+// maxMessage bounds the payload of a message read from a socket.
+const maxMessage = 64 << 20
+
+// I3 follows the focus through the IPC protocol of i3 and sway.
+type I3 struct {
+	path string
+}
+
+// NewI3 returns a client for the i3 or sway IPC socket at path.
+func NewI3(path string) *I3 {
+	return &I3{path: path}
+}
+
+// Name implements Client.
+func (c *I3) Name() string {
+	return "i3/sway"
+}
+
+// i3Node is the part of a GET_TREE node the focus is read from.
+type i3Node struct {
+	Type             string `json:"type"`
+	Focused          bool   `json:"focused"`
+	FullscreenMode   int    `json:"fullscreen_mode"`
+	AppID            string `json:"app_id"` // sway only, for Wayland windows
+	PID              uint32 `json:"pid"`    // sway only
+	Window           uint32 `json:"window"` // X11 window ID
+	Rect             Rect   `json:"rect"`
+	WindowProperties struct {
+		Class    string `json:"class"`
+		Instance string `json:"instance"`
+	} `json:"window_properties"`
+	Nodes         []i3Node `json:"nodes"`
+	FloatingNodes []i3Node `json:"floating_nodes"`
+}
+
+// Watch implements Client. The focused window is read from the whole tree
+// on every window and workspace event, since switching to an empty
+// workspace or fullscreening a parent container changes it without a
+// window event for the window itself.
+func (c *I3) Watch(fn func(Window)) error {
+	events, err := net.Dial("unix", c.path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", c.path, err)
+	}
+	defer events.Close()
+
+	queries, err := net.Dial("unix", c.path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", c.path, err)
+	}
+	defer queries.Close()
+
+	if err := writeI3(events, i3Subscribe, []byte(`["window","workspace"]`)); err != nil {
+		return err
+	}
+	_, payload, err := readI3(events)
+	if err != nil {
+		return err
+	}
+	var reply struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(payload, &reply); err != nil || !reply.Success {
+		return fmt.Errorf("failed to subscribe to i3 events: %s", payload)
+	}
+
+	last, err := focusedI3(queries)
+	if err != nil {
+		return err
+	}
+	fn(last)
+
+	for {
+		typ, _, err := readI3(events)
+		if err != nil {
+			return err
+		}
+		if typ != i3EventWindow && typ != i3EventWorkspace {
+			continue
+		}
+
+		w, err := focusedI3(queries)
+		if err != nil {
+			return err
+		}
+		if w != last {
+			last = w
+			fn(w)
+		}
+	}
+}
+
+// focusedI3 asks for the tree and returns the focused window in it.
+func focusedI3(conn net.Conn) (Window, error) {
+	if err := writeI3(conn, i3GetTree, nil); err != nil {
+		return Window{}, err
+	}
+	_, payload, err := readI3(conn)
+	if err != nil {
+		return Window{}, err
+	}
+
+	var root i3Node
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return Window{}, fmt.Errorf("invalid i3 tree: %w", err)
+	}
+	w, _ := root.focused(nil)
+	return w, nil
+}
+
+// focused searches the tree below n for the focused window. fullscreen is
+// the outermost fullscreen container above n, or nil; workspaces are
+// skipped, since i3 marks them fullscreen too.
+func (n *i3Node) focused(fullscreen *i3Node) (Window, bool) {
+	isContainer := n.Type == "con" || n.Type == "floating_con"
+	if fullscreen == nil && isContainer && n.FullscreenMode != 0 {
+		fullscreen = n
+	}
+
+	if n.Focused {
+		if !isContainer || n.Window == 0 && n.AppID == "" {
+			return Window{}, true
+		}
+		w := Window{
+			Class:      n.WindowProperties.Class,
+			Instance:   n.WindowProperties.Instance,
+			PID:        n.PID,
+			XID:        n.Window,
+			Fullscreen: fullscreen != nil,
+			Area:       n.Rect,
+		}
+		if fullscreen != nil {
+			w.Area = fullscreen.Rect
+		}
+		if n.AppID != "" {
+			w.Class, w.Instance = n.AppID, n.AppID
+		}
+		return w, true
+	}
+
+	for _, list := range [][]i3Node{n.Nodes, n.FloatingNodes} {
+		for i := range list {
+			if w, ok := list[i].focused(fullscreen); ok {
+				return w, true
+			}
+		}
+	}
+	return Window{}, false
+}
+
+// writeI3 sends one i3 IPC message: the magic string, the payload length
+// and the message type in native byte order, then the payload.
+func writeI3(w io.Writer, typ uint32, payload []byte) error {
+	msg := make([]byte, len(i3Magic)+8, len(i3Magic)+8+len(payload))
+	copy(msg, i3Magic)
+	binary.NativeEndian.PutUint32(msg[len(i3Magic):], uint32(len(payload)))
+	binary.NativeEndian.PutUint32(msg[len(i3Magic)+4:], typ)
+	msg = append(msg, payload...)
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to send i3 message: %w", err)
+	}
+	return nil
+}
+
+// readI3 reads one i3 IPC reply or event.
+func readI3(r io.Reader) (uint32, []byte, error) {
+	header := make([]byte, len(i3Magic)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, fmt.Errorf("failed to read i3 message: %w", err)
+	}
+	if string(header[:len(i3Magic)]) != i3Magic {
+		return 0, nil, fmt.Errorf("invalid i3 message header %q", header)
+	}
+
+	size := binary.NativeEndian.Uint32(header[len(i3Magic):])
+	typ := binary.NativeEndian.Uint32(header[len(i3Magic)+4:])
+	if size > maxMessage {
+		return 0, nil, fmt.Errorf("i3 message too large (%d bytes)", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, fmt.Errorf("failed to read i3 message: %w", err)
+	}
+	return typ, payload, nil
+}
@@ -0,0 +1,125 @@
+package compositor
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// serveI3 starts a fake i3 IPC server. It answers the subscription with
+// subscribeReply and the GET_TREE requests with trees in turn, sending a
+// window event after each tree but the last and then closing the event
+// connection.
+func serveI3(t *testing.T, subscribeReply string, trees []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "i3.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		events, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer events.Close()
+		queries, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer queries.Close()
+
+		if typ, payload, err := readI3(events); err != nil || typ != i3Subscribe ||
+			string(payload) != `["window","workspace"]` {
+			t.Errorf("subscribe request = %d %q, %v", typ, payload, err)
+			return
+		}
+		if err := writeI3(events, i3Subscribe, []byte(subscribeReply)); err != nil {
+			return
+		}
+
+		for i, tree := range trees {
+			if typ, _, err := readI3(queries); err != nil || typ != i3GetTree {
+				return
+			}
+			if err := writeI3(queries, i3GetTree, []byte(tree)); err != nil {
+				return
+			}
+			if i+1 < len(trees) {
+				if err := writeI3(events, i3EventWindow, []byte(`{"change":"focus"}`)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return path
+}
+
+// watchAll runs c.Watch until it fails and returns the windows it reported.
+func watchAll(t *testing.T, c Client) ([]Window, error) {
+	t.Helper()
+	var got []Window
+	err := c.Watch(func(w Window) {
+		got = append(got, w)
+	})
+	return got, err
+}
+
+func TestI3WatchSubscribeFailure(t *testing.T) {
+	path := serveI3(t, `{"success":false}`, nil)
+
+	got, err := watchAll(t, NewI3(path))
+	if err == nil || !strings.Contains(err.Error(), "subscribe") {
+		t.Fatalf("Watch error = %v, want a subscribe failure", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Watch reported %v before subscribing", got)
+	}
+}
+
+func TestI3WatchFocus(t *testing.T) {
+	// An X window inside a fullscreen split container: the window itself is
+	// not fullscreen, but covers the output through its parent.
+	inherited := `{"type":"root","nodes":[{"type":"output","nodes":[
+		{"type":"workspace","fullscreen_mode":1,"rect":{"x":0,"y":0,"width":1920,"height":1080},"nodes":[
+			{"type":"con","fullscreen_mode":1,"rect":{"x":0,"y":0,"width":1920,"height":1080},"nodes":[
+				{"type":"con","focused":true,"window":4194305,"rect":{"x":0,"y":0,"width":960,"height":1080},
+				 "window_properties":{"class":"cs2","instance":"cs2.sh"}},
+				{"type":"con","window":4194306,"rect":{"x":960,"y":0,"width":960,"height":1080}}
+			]}
+		]}
+	]}]}`
+	// A Wayland window on a workspace: i3 marks workspaces fullscreen too,
+	// which must not count.
+	wayland := `{"type":"root","nodes":[{"type":"output","nodes":[
+		{"type":"workspace","fullscreen_mode":1,"nodes":[],"floating_nodes":[
+			{"type":"floating_con","focused":true,"app_id":"foot","pid":42,
+			 "rect":{"x":10,"y":20,"width":500,"height":400}}
+		]}
+	]}]}`
+	// An empty workspace has the focus itself.
+	empty := `{"type":"root","nodes":[{"type":"output","nodes":[
+		{"type":"workspace","focused":true,"fullscreen_mode":1,"nodes":[]}
+	]}]}`
+
+	path := serveI3(t, `{"success":true}`, []string{inherited, inherited, wayland, empty})
+
+	got, err := watchAll(t, NewI3(path))
+	if err == nil {
+		t.Fatal("Watch returned without an error after the connection closed")
+	}
+
+	want := []Window{
+		{Class: "cs2", Instance: "cs2.sh", XID: 4194305, Fullscreen: true,
+			Area: Rect{Width: 1920, Height: 1080}},
+		{Class: "foot", Instance: "foot", PID: 42, Area: Rect{X: 10, Y: 20, Width: 500, Height: 400}},
+		{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Watch reported\n%+v\nwant\n%+v", got, want)
+	}
+}
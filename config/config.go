@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,8 +16,17 @@ import (
 
 // Config represents the complete application configuration.
 type Config struct {
-	Crosshair CrosshairConfig `toml:"crosshair"`
-	Position  PositionConfig  `toml:"position"`
+	ActiveProfile string             `toml:"active_profile,omitempty"`
+	Crosshair     CrosshairConfig    `toml:"crosshair"`
+	Position      PositionConfig     `toml:"position"`
+	Focus         FocusConfig        `toml:"focus,omitempty"`
+	Keys          map[string]string  `toml:"keys,omitempty"`
+	Mouse         MouseConfig        `toml:"mouse"`
+	Movement      MovementConfig     `toml:"movement"`
+	Hitmarker     HitmarkerConfig    `toml:"hitmarker"`
+	Cursor        CursorConfig       `toml:"cursor"`
+	Idle          IdleConfig         `toml:"idle"`
+	Profiles      map[string]Profile `toml:"profiles,omitempty"`
 }
 
 // CrosshairConfig contains crosshair appearance settings.
@@ -34,9 +42,18 @@ type CrosshairConfig struct {
 
 // PositionConfig contains crosshair positioning settings.
 type PositionConfig struct {
-	Monitor int `toml:"monitor"`
-	OffsetX int `toml:"offset_x"`
-	OffsetY int `toml:"offset_y"`
+	Monitor     int    `toml:"monitor"`
+	OffsetX     int    `toml:"offset_x"`
+	OffsetY     int    `toml:"offset_y"`
+	Anchor      string `toml:"anchor"`
+	WindowClass string `toml:"window_class,omitempty"`
+	WindowTitle string `toml:"window_title,omitempty"`
+	Visibility  string `toml:"visibility"`
+	// Desktops limits the crosshair to these virtual desktops, numbered
+	// from 0 as in _NET_CURRENT_DESKTOP. Empty shows it on every desktop.
+	// As a list it is not in Fields, so it is only read from the config
+	// file and checked by Validate directly.
+	Desktops []int `toml:"desktops,omitempty"`
 }
 
 // GetConfigDir returns the configuration directory path following XDG spec.
@@ -60,8 +77,9 @@ func GetConfigPath() string {
 }
 
 // Load reads and parses a configuration file from the given path.
+// Settings missing from the file keep their default values.
 func Load(path string) (*Config, error) {
-	cfg := &Config{}
+	cfg := Default()
 
 	if _, err := toml.DecodeFile(path, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
@@ -70,6 +88,20 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// LoadValid loads the configuration at path and checks it with Validate.
+func LoadValid(path string) (*Config, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
 // Save writes the configuration to the given path.
 func Save(path string, cfg *Config) error {
 	dir := filepath.Dir(path)
@@ -128,40 +160,68 @@ func LoadOrCreate(path string) (*Config, bool, error) {
 }
 
 // Validate checks if the configuration values are valid.
+// The rules for each setting come from Fields. Every profile is resolved
+// through its extends chain, so unknown parents and cycles are reported too.
 func (c *Config) Validate() error {
 	var errs []string
 
-	if !slices.Contains(ValidShapes, c.Crosshair.Shape) {
-		errs = append(errs, fmt.Sprintf("invalid shape %q (must be one of: %s)",
-			c.Crosshair.Shape, strings.Join(ValidShapes, ", ")))
-	}
-
-	if _, err := ParseColor(c.Crosshair.Color); err != nil {
-		errs = append(errs, fmt.Sprintf("invalid color %q: %v", c.Crosshair.Color, err))
+	for _, f := range Fields {
+		if err := f.Check(c); err != nil {
+			errs = append(errs, err.Error())
+		}
 	}
-
-	if _, err := ParseColor(c.Crosshair.OutlineColor); err != nil {
-		errs = append(errs, fmt.Sprintf("invalid outline_color %q: %v", c.Crosshair.OutlineColor, err))
+	anchorErr := c.checkAnchor()
+	if anchorErr != nil {
+		errs = append(errs, anchorErr.Error())
 	}
 
-	if c.Crosshair.Size < 1 || c.Crosshair.Size > 500 {
-		errs = append(errs, fmt.Sprintf("size must be between 1 and 500 (got %d)", c.Crosshair.Size))
+	// A broken profile is reported once, not again for each profile extending
+	// it, and a cycle once rather than for each profile in or leading into it.
+	seen := make(map[string]bool)
+	for _, name := range c.ProfileNames() {
+		resolved, err := c.Resolve(name)
+		if err == nil && anchorErr == nil {
+			if err = resolved.checkAnchor(); err != nil {
+				err = fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+		if err == nil {
+			continue
+		}
+		key := err.Error()
+		var cycle *cycleError
+		if errors.As(err, &cycle) {
+			key = "cycle " + cycle.cycle()
+		}
+		if !seen[key] {
+			seen[key] = true
+			errs = append(errs, err.Error())
+		}
 	}
 
-	if c.Crosshair.Thickness < 1 || c.Crosshair.Thickness > 100 {
-		errs = append(errs, fmt.Sprintf("thickness must be between 1 and 100 (got %d)", c.Crosshair.Thickness))
+	switch unmatched := c.Focus.Unmatched; unmatched {
+	case "", UnmatchedDefault, UnmatchedHide:
+	default:
+		if _, ok := c.Profiles[unmatched]; !ok {
+			errs = append(errs, fmt.Sprintf("focus.unmatched %q is not %q, %q or a defined profile",
+				unmatched, UnmatchedDefault, UnmatchedHide))
+		}
 	}
 
-	if c.Crosshair.Gap < 0 || c.Crosshair.Gap > 100 {
-		errs = append(errs, fmt.Sprintf("gap must be between 0 and 100 (got %d)", c.Crosshair.Gap))
+	for _, d := range c.Position.Desktops {
+		if d < 0 {
+			errs = append(errs, fmt.Sprintf("position.desktops: desktop numbers start at 0 (got %d)", d))
+		}
 	}
 
-	if c.Crosshair.OutlineThickness < 0 || c.Crosshair.OutlineThickness > 50 {
-		errs = append(errs, fmt.Sprintf("outline_thickness must be between 0 and 50 (got %d)", c.Crosshair.OutlineThickness))
-	}
+	errs = append(errs, c.checkKeys()...)
+	errs = append(errs, c.checkMouse()...)
+	errs = append(errs, c.checkMovement()...)
 
-	if c.Position.Monitor < -1 || c.Position.Monitor > 100 {
-		errs = append(errs, fmt.Sprintf("monitor must be between -1 and 100 (got %d)", c.Position.Monitor))
+	if c.ActiveProfile != "" {
+		if _, ok := c.Profiles[c.ActiveProfile]; !ok {
+			errs = append(errs, fmt.Sprintf("active_profile %q is not defined", c.ActiveProfile))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -171,6 +231,15 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// checkAnchor reports a window anchor that has nothing to find the window by.
+func (c *Config) checkAnchor() error {
+	p := c.Position
+	if p.Anchor == AnchorWindow && p.WindowClass == "" && p.WindowTitle == "" {
+		return fmt.Errorf("anchor %q needs window_class or window_title", AnchorWindow)
+	}
+	return nil
+}
+
 // ParseColor parses a hex color string and returns the uint32 value.
 // Supports formats: #RRGGBB, 0xRRGGBB, RRGGBB
 func ParseColor(s string) (uint32, error) {
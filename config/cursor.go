@@ -0,0 +1,16 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+// DefaultCursorDebounce is how long the cursor must stay shown or hidden
+// before the crosshair follows, in milliseconds.
+const DefaultCursorDebounce = 100
+
+// CursorConfig controls hiding the crosshair while the mouse cursor is shown,
+// as games do in menus and inventories.
+type CursorConfig struct {
+	// AutoHide hides the crosshair while the cursor is visible.
+	AutoHide bool `toml:"auto_hide"`
+	// Debounce is how long the cursor must stay visible or hidden before
+	// the crosshair is hidden or shown, in milliseconds.
+	Debounce int `toml:"debounce_ms"`
+}
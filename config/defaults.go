@@ -13,11 +13,50 @@ const (
 	DefaultMonitor          = 0
 	DefaultOffsetX          = 0
 	DefaultOffsetY          = 0
+	DefaultAnchor           = AnchorMonitor
+	DefaultVisibility       = VisibilityAlways
 )
 
 // Valid shape options.
 var ValidShapes = []string{"cross", "dot", "circle", "cross-dot"}
 
+// ColorPreset is a named color.
+type ColorPreset struct {
+	Name  string
+	Value string
+}
+
+// ColorPresets are the colors the setup wizard offers and the next_color
+// hotkey cycles through.
+var ColorPresets = []ColorPreset{
+	{"Green", "#00FF00"},
+	{"Red", "#FF0000"},
+	{"White", "#FFFFFF"},
+	{"Cyan", "#00FFFF"},
+	{"Yellow", "#FFFF00"},
+	{"Pink", "#FF00FF"},
+	{"Orange", "#FFA500"},
+}
+
+// Anchor modes: what the crosshair is centered on before offsets are added.
+const (
+	AnchorMonitor = "monitor" // The selected monitor
+	AnchorWindow  = "window"  // A window matched by window_class or window_title
+	AnchorPointer = "pointer" // The mouse pointer
+)
+
+// Valid anchor options.
+var ValidAnchors = []string{AnchorMonitor, AnchorWindow, AnchorPointer}
+
+// Visibility modes.
+const (
+	VisibilityAlways         = "always"          // Always show the crosshair
+	VisibilityFullscreenOnly = "fullscreen-only" // Only over a fullscreen window on the chosen monitor
+)
+
+// Valid visibility options.
+var ValidVisibilities = []string{VisibilityAlways, VisibilityFullscreenOnly}
+
 // Default returns a new Config with default values.
 func Default() *Config {
 	return &Config{
@@ -31,9 +70,35 @@ func Default() *Config {
 			OutlineColor:     DefaultOutlineColor,
 		},
 		Position: PositionConfig{
-			Monitor: DefaultMonitor,
-			OffsetX: DefaultOffsetX,
-			OffsetY: DefaultOffsetY,
+			Monitor:    DefaultMonitor,
+			OffsetX:    DefaultOffsetX,
+			OffsetY:    DefaultOffsetY,
+			Anchor:     DefaultAnchor,
+			Visibility: DefaultVisibility,
+		},
+		Mouse: MouseConfig{
+			SpreadRamp:    DefaultSpreadRamp,
+			SpreadRecover: DefaultSpreadRecover,
+		},
+		Movement: MovementConfig{
+			Keys:          append([]string(nil), DefaultMovementKeys...),
+			SpreadRamp:    DefaultMovementRamp,
+			SpreadRecover: DefaultMovementRecover,
+			Curve:         DefaultMovementCurve,
+		},
+		Hitmarker: HitmarkerConfig{
+			Color:     DefaultHitmarkerColor,
+			Size:      DefaultHitmarkerSize,
+			Gap:       DefaultHitmarkerGap,
+			Thickness: DefaultHitmarkerThickness,
+			Duration:  DefaultHitmarkerDuration,
+			FadeSteps: DefaultHitmarkerFadeSteps,
+		},
+		Cursor: CursorConfig{
+			Debounce: DefaultCursorDebounce,
+		},
+		Idle: IdleConfig{
+			HideOnLock: true,
 		},
 	}
 }
@@ -72,5 +137,115 @@ monitor = 0
 # Positive X = right, Positive Y = down
 offset_x = 0
 offset_y = 0
+
+# What the crosshair is centered on: "monitor", "window" to follow a
+# windowed game, or "pointer" to follow the mouse pointer. With "window",
+# set window_class and/or window_title to regular expressions matching the
+# game window's WM_CLASS and title.
+anchor = "monitor"
+# window_class = "^steam_app_730$"
+# window_title = "Counter-Strike"
+
+# When to show the crosshair: "always", or "fullscreen-only" to show it
+# only while a fullscreen window is focused on the chosen monitor
+visibility = "always"
+
+# Show the crosshair only on these virtual desktops, numbered from 0 in
+# the order the window manager lists them. Leave unset for every desktop.
+# Only set here: profiles, the wizard and --set cannot change it.
+# desktops = [2]
+
+# Reacting to the mouse buttons, which are read without taking them away
+# from the game. While the right button is held, aim = "hide" hides the
+# crosshair and aim = "<profile>" switches to that profile, for games that
+# draw their own scope. While the left button is held the gap widens by
+# spread pixels over spread_ramp_ms, and closes again over
+# spread_recover_ms after release.
+#
+# [mouse]
+# aim = "hide"
+# spread = 6
+# spread_ramp_ms = 150
+# spread_recover_ms = 250
+
+# Dynamic spread while moving, as in CS-style crosshairs: while any of
+# the movement keys is held the gap widens and the arms grow by spread
+# pixels, then shrink back after release. The keys are read without
+# taking them away from the game. curve is "linear" or "smooth".
+#
+# [movement]
+# keys = ["w", "a", "s", "d"]
+# spread = 8
+# spread_ramp_ms = 120
+# spread_recover_ms = 250
+# curve = "smooth"
+
+# Hitmarker: a diagonal X flashed around the center on each left click.
+# It lasts duration_ms and shrinks away in fade_steps steps.
+#
+# [hitmarker]
+# enabled = true
+# color = "#FFFFFF"
+# size = 6          # Length of each arm
+# gap = 4           # Distance of the arms from the center
+# thickness = 2
+# duration_ms = 150
+# fade_steps = 3
+
+# Hide the crosshair while the game shows the mouse cursor, as in menus
+# and inventories. The cursor must stay shown or hidden for debounce_ms
+# before the crosshair follows. Needs the XFixes extension.
+#
+# [cursor]
+# auto_hide = true
+# debounce_ms = 100
+
+# Hide the crosshair after the keyboard and mouse have been idle for
+# hide_after_s seconds, and while the screen is locked or the screensaver
+# runs. It comes back on the next key press or mouse movement. Needs the
+# MIT-SCREEN-SAVER extension.
+#
+# [idle]
+# hide_after_s = 300
+# hide_on_lock = true
+
+# Global hotkeys, each a key with optional modifiers (shift, ctrl, alt,
+# super) joined by "+". Keys use X keysym names such as "h", "F9", "Up"
+# or "KP_Add". Changes made with hotkeys last until the crosshair exits.
+#
+# [keys]
+# toggle = "ctrl+alt+h"            # Show or hide the crosshair
+# hold = "F9"                      # Show the crosshair only while held
+# next_profile = "ctrl+alt+Next"   # Cycle through the profiles
+# previous_profile = "ctrl+alt+Prior"
+# nudge_left = "ctrl+alt+Left"     # Move the crosshair by one pixel
+# nudge_right = "ctrl+alt+Right"
+# nudge_up = "ctrl+alt+Up"
+# nudge_down = "ctrl+alt+Down"
+# grow = "ctrl+alt+KP_Add"         # Change the size by one pixel
+# shrink = "ctrl+alt+KP_Subtract"
+# next_color = "ctrl+alt+c"        # Cycle through the preset colors
+
+# On sway, i3 and Hyprland, read the focused window from the compositor's
+# IPC socket instead of X. Under XWayland, X does not see focus moving to
+# native Wayland windows.
+#
+# [focus]
+# ipc = true
+
+# Profiles override any of the settings above, one table per profile.
+# A profile can extend another and only changes the settings it lists.
+# Choose one with active_profile = "name" at the top of this file,
+# or with: gocrosshair -profile name
+#
+# [profiles.valorant]
+# color = "#FF00FF"
+# size = 6
+# watch_cmdline = "VALORANT"   # Start with this profile from: gocrosshair watch
+#
+# [profiles.valorant-sniper]
+# extends = "valorant"
+# shape = "dot"
+# trigger_keys = ["3"]   # Switch to this profile when 3 is pressed
 `
 }
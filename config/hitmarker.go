@@ -0,0 +1,30 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+// Default hitmarker settings.
+const (
+	DefaultHitmarkerColor     = "#FFFFFF"
+	DefaultHitmarkerSize      = 6
+	DefaultHitmarkerGap       = 4
+	DefaultHitmarkerThickness = 2
+	DefaultHitmarkerDuration  = 150
+	DefaultHitmarkerFadeSteps = 3
+)
+
+// HitmarkerConfig controls the diagonal X flashed around the center on
+// each left click.
+type HitmarkerConfig struct {
+	Enabled   bool   `toml:"enabled"`
+	Color     string `toml:"color"`
+	Size      int    `toml:"size"`        // Length of each diagonal arm in pixels
+	Gap       int    `toml:"gap"`         // Distance of the arms from the center in pixels
+	Thickness int    `toml:"thickness"`   // Thickness of the arms in pixels
+	Duration  int    `toml:"duration_ms"` // How long the flash lasts in milliseconds
+	FadeSteps int    `toml:"fade_steps"`  // Steps in which the arms shrink away
+}
+
+// GetHitmarkerColorUint32 returns the hitmarker color as uint32.
+func (c *Config) GetHitmarkerColorUint32() uint32 {
+	color, _ := ParseColor(c.Hitmarker.Color)
+	return color
+}
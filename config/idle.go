@@ -0,0 +1,17 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+// maxIdle is the longest accepted idle period in seconds.
+const maxIdle = 24 * 60 * 60
+
+// IdleConfig controls hiding the crosshair while nobody is using the
+// computer: after a period without input, and while the screen is locked
+// or the screensaver runs.
+type IdleConfig struct {
+	// HideAfter is how long the keyboard and mouse must stay untouched
+	// before the crosshair is hidden, in seconds. 0 turns it off.
+	HideAfter int `toml:"hide_after_s,omitempty"`
+	// HideOnLock hides the crosshair while the screen is locked or the
+	// screensaver runs.
+	HideOnLock bool `toml:"hide_on_lock"`
+}
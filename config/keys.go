@@ -0,0 +1,160 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Hotkey actions, the keys of the [keys] table.
+const (
+	ActionToggle          = "toggle"           // Show or hide the crosshair
+	ActionHold            = "hold"             // Show the crosshair only while held
+	ActionNextProfile     = "next_profile"     // Switch to the next profile
+	ActionPreviousProfile = "previous_profile" // Switch to the previous profile
+	ActionNudgeLeft       = "nudge_left"       // Move the crosshair one pixel left
+	ActionNudgeRight      = "nudge_right"      // Move the crosshair one pixel right
+	ActionNudgeUp         = "nudge_up"         // Move the crosshair one pixel up
+	ActionNudgeDown       = "nudge_down"       // Move the crosshair one pixel down
+	ActionGrow            = "grow"             // Increase the size by one pixel
+	ActionShrink          = "shrink"           // Decrease the size by one pixel
+	ActionNextColor       = "next_color"       // Switch to the next preset color
+)
+
+// ValidActions lists the hotkey actions in the order they are documented.
+var ValidActions = []string{
+	ActionToggle, ActionHold,
+	ActionNextProfile, ActionPreviousProfile,
+	ActionNudgeLeft, ActionNudgeRight, ActionNudgeUp, ActionNudgeDown,
+	ActionGrow, ActionShrink, ActionNextColor,
+}
+
+// Modifier masks of a Chord, the same bits the X protocol uses.
+const (
+	ModShift   uint16 = 1 << 0
+	ModControl uint16 = 1 << 2
+	ModAlt     uint16 = 1 << 3 // Mod1
+	ModSuper   uint16 = 1 << 6 // Mod4
+)
+
+// modifierNames maps the modifier names accepted in a chord to their masks.
+var modifierNames = map[string]uint16{
+	"shift":   ModShift,
+	"ctrl":    ModControl,
+	"control": ModControl,
+	"alt":     ModAlt,
+	"mod1":    ModAlt,
+	"super":   ModSuper,
+	"win":     ModSuper,
+	"mod4":    ModSuper,
+}
+
+// Chord is a key combined with modifiers, such as ctrl+alt+h.
+type Chord struct {
+	Modifiers uint16 // ModShift, ModControl, ModAlt and ModSuper bits
+	Keysym    uint32 // X keysym of the key
+}
+
+// keysyms maps the keysym names accepted in a chord to their values.
+var keysyms = func() map[string]uint32 {
+	m := map[string]uint32{
+		"space": 0x0020, "apostrophe": 0x0027, "comma": 0x002c, "minus": 0x002d,
+		"period": 0x002e, "slash": 0x002f, "semicolon": 0x003b, "equal": 0x003d,
+		"bracketleft": 0x005b, "backslash": 0x005c, "bracketright": 0x005d, "grave": 0x0060,
+
+		"BackSpace": 0xff08, "Tab": 0xff09, "Return": 0xff0d, "Pause": 0xff13,
+		"Scroll_Lock": 0xff14, "Escape": 0xff1b, "Home": 0xff50, "Left": 0xff51,
+		"Up": 0xff52, "Right": 0xff53, "Down": 0xff54, "Page_Up": 0xff55,
+		"Prior": 0xff55, "Page_Down": 0xff56, "Next": 0xff56, "End": 0xff57,
+		"Print": 0xff61, "Insert": 0xff63, "Menu": 0xff67, "Delete": 0xffff,
+
+		"KP_Enter": 0xff8d, "KP_Multiply": 0xffaa, "KP_Add": 0xffab,
+		"KP_Subtract": 0xffad, "KP_Decimal": 0xffae, "KP_Divide": 0xffaf,
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		m[string(c)] = uint32(c)
+	}
+	for i := range 10 {
+		m[fmt.Sprint(i)] = uint32('0' + i)
+		m[fmt.Sprintf("KP_%d", i)] = uint32(0xffb0 + i)
+	}
+	for i := 1; i <= 24; i++ {
+		m[fmt.Sprintf("F%d", i)] = uint32(0xffbe + i - 1)
+	}
+	return m
+}()
+
+// LookupKeysym returns the keysym with the given name. Names are matched
+// case-insensitively, so "f9" and "page_up" work as well as "F9" and "Page_Up".
+func LookupKeysym(name string) (uint32, bool) {
+	if sym, ok := keysyms[name]; ok {
+		return sym, true
+	}
+	for n, sym := range keysyms {
+		if strings.EqualFold(n, name) {
+			return sym, true
+		}
+	}
+	return 0, false
+}
+
+// ParseChord parses a key chord written as modifiers and a keysym name
+// joined by "+", such as "ctrl+alt+h", "super+Up" or "F9".
+func ParseChord(s string) (Chord, error) {
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	key := strings.TrimSpace(parts[len(parts)-1])
+	if key == "" {
+		return Chord{}, fmt.Errorf("key chord %q has no key", s)
+	}
+
+	var chord Chord
+	for _, part := range parts[:len(parts)-1] {
+		mod, ok := modifierNames[strings.ToLower(strings.TrimSpace(part))]
+		if !ok {
+			return Chord{}, fmt.Errorf("unknown modifier %q in key chord %q (use shift, ctrl, alt or super)", part, s)
+		}
+		chord.Modifiers |= mod
+	}
+
+	sym, ok := LookupKeysym(key)
+	if !ok {
+		return Chord{}, fmt.Errorf("unknown key %q in key chord %q", key, s)
+	}
+	chord.Keysym = sym
+
+	return chord, nil
+}
+
+// checkKeys reports unknown actions, chords that do not parse, and chords
+// bound to more than one action.
+func (c *Config) checkKeys() []string {
+	var errs []string
+	used := make(map[Chord]string)
+
+	actions := make([]string, 0, len(c.Keys))
+	for action := range c.Keys {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+
+	for _, action := range actions {
+		if !slices.Contains(ValidActions, action) {
+			errs = append(errs, fmt.Sprintf("unknown hotkey action keys.%s (must be one of: %s)",
+				action, strings.Join(ValidActions, ", ")))
+			continue
+		}
+		chord, err := ParseChord(c.Keys[action])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("keys.%s: %v", action, err))
+			continue
+		}
+		if other, ok := used[chord]; ok {
+			errs = append(errs, fmt.Sprintf("keys.%s and keys.%s are both bound to %q", other, action, c.Keys[action]))
+			continue
+		}
+		used[chord] = action
+	}
+
+	return errs
+}
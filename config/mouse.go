@@ -0,0 +1,47 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+import "fmt"
+
+// AimHide is the value of MouseConfig.Aim that hides the crosshair.
+const AimHide = "hide"
+
+// Default mouse settings.
+const (
+	DefaultSpreadRamp    = 150 // Milliseconds for the gap to widen fully
+	DefaultSpreadRecover = 250 // Milliseconds for the gap to close after firing
+	maxSpread            = 100
+	maxDuration          = 5000 // Longest accepted duration in milliseconds
+)
+
+// MouseConfig controls how the crosshair reacts to the mouse buttons.
+type MouseConfig struct {
+	// Aim is what happens while the right button is held: nothing when
+	// empty, AimHide to hide the crosshair, or the name of a profile to
+	// switch to, such as a scope style.
+	Aim string `toml:"aim,omitempty"`
+	// Spread is how many pixels the gap widens while the left button is
+	// held, 0 to keep it fixed.
+	Spread int `toml:"spread,omitempty"`
+	// SpreadRamp is how long the gap takes to widen fully, in milliseconds.
+	SpreadRamp int `toml:"spread_ramp_ms"`
+	// SpreadRecover is how long the gap takes to close after the left
+	// button is released, in milliseconds.
+	SpreadRecover int `toml:"spread_recover_ms"`
+}
+
+// WatchesButtons reports whether the crosshair reacts to the mouse buttons.
+func (m MouseConfig) WatchesButtons() bool {
+	return m.Aim != "" || m.Spread > 0
+}
+
+// checkMouse reports a mouse.aim that names an undefined profile. The
+// other mouse settings are checked with Fields.
+func (c *Config) checkMouse() []string {
+	if aim := c.Mouse.Aim; aim != "" && aim != AimHide {
+		if _, ok := c.Profiles[aim]; !ok {
+			return []string{fmt.Sprintf("mouse.aim %q is not %q or a defined profile", aim, AimHide)}
+		}
+	}
+	return nil
+}
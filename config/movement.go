@@ -0,0 +1,63 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+import "fmt"
+
+// Spread curves: how the crosshair opens and closes between rest and full spread.
+const (
+	CurveLinear = "linear" // Constant speed
+	CurveSmooth = "smooth" // Eases in and out
+)
+
+// Valid curve options.
+var ValidCurves = []string{CurveLinear, CurveSmooth}
+
+// Default movement settings.
+const (
+	DefaultMovementRamp    = 120 // Milliseconds to open fully
+	DefaultMovementRecover = 250 // Milliseconds to close after stopping
+	DefaultMovementCurve   = CurveSmooth
+)
+
+// DefaultMovementKeys are the movement keys of most games.
+var DefaultMovementKeys = []string{"w", "a", "s", "d"}
+
+// MovementConfig controls the dynamic spread while movement keys are held,
+// as in CS-style crosshairs: the gap widens and the arms grow longer.
+type MovementConfig struct {
+	// Keys are the keysym names of the movement keys.
+	Keys []string `toml:"keys"`
+	// Spread is how many pixels the gap widens, and the arms grow, while
+	// any of the keys is held. 0 turns the dynamic spread off.
+	Spread int `toml:"spread,omitempty"`
+	// SpreadRamp is how long the crosshair takes to open fully, in milliseconds.
+	SpreadRamp int `toml:"spread_ramp_ms"`
+	// SpreadRecover is how long it takes to close after the keys are
+	// released, in milliseconds.
+	SpreadRecover int `toml:"spread_recover_ms"`
+	// Curve is how the crosshair moves between rest and full spread.
+	Curve string `toml:"curve"`
+}
+
+// Keysyms returns the keysyms of the movement keys, skipping unknown names.
+func (m MovementConfig) Keysyms() []uint32 {
+	var syms []uint32
+	for _, name := range m.Keys {
+		if sym, ok := LookupKeysym(name); ok {
+			syms = append(syms, sym)
+		}
+	}
+	return syms
+}
+
+// checkMovement reports unknown movement keys. The other movement settings
+// are checked with Fields.
+func (c *Config) checkMovement() []string {
+	var errs []string
+	for _, name := range c.Movement.Keys {
+		if _, ok := LookupKeysym(name); !ok {
+			errs = append(errs, fmt.Sprintf("movement.keys: unknown key %q", name))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,376 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Profile keys that are not settings.
+const (
+	extendsKey    = "extends"       // Name of the profile to inherit from
+	matchClassKey = "match_class"   // Regular expression for the focused window's WM_CLASS
+	matchExeKey   = "match_exe"     // Executable name of the focused window's process
+	triggerKeyKey = "trigger_keys"  // Keys that switch to the profile, such as weapon slots
+	watchExeKey   = "watch_exe"     // Executable name of a process that starts the overlay
+	watchCmdKey   = "watch_cmdline" // Regular expression for the command line of such a process
+)
+
+// Values of FocusConfig.Unmatched with a special meaning.
+const (
+	UnmatchedDefault = "default" // Use the profile chosen at launch
+	UnmatchedHide    = "hide"    // Hide the crosshair
+)
+
+// FocusConfig controls automatic profile switching on window focus.
+type FocusConfig struct {
+	// Unmatched is what to show when no profile matches the focused window:
+	// UnmatchedDefault (or empty), UnmatchedHide, or the name of a profile.
+	Unmatched string `toml:"unmatched,omitempty"`
+	// IPC reads the focused window from the IPC socket of sway, i3 or
+	// Hyprland instead of X, which also sees native Wayland windows.
+	IPC bool `toml:"ipc,omitempty"`
+}
+
+// Profile is a named set of overrides, written as a [profiles.<name>] table.
+// Its keys are the names of Fields, such as size or hitmarker.color, plus an
+// optional "extends" naming another profile. A dotted name is read by TOML as
+// a nested table, so settings may also be grouped as [profiles.<name>.hitmarker].
+// Settings a profile does not set are inherited from the profile it extends,
+// or from the base tables.
+//
+// A profile may also set "match_class" and "match_exe" to be switched to
+// automatically when a matching window is focused, or "trigger_keys" to be
+// switched to when one of the keys is pressed, such as a weapon slot. A
+// profile with trigger keys is not switched to on focus; its match rules
+// limit the keys to matching windows instead. "watch_exe" and
+// "watch_cmdline" name the game process that makes `gocrosshair watch`
+// start the overlay with the profile. None of these are inherited.
+type Profile map[string]any
+
+// Extends returns the name of the profile p inherits from, or "" if it
+// inherits from the base configuration.
+func (p Profile) Extends() string {
+	name, _ := p[extendsKey].(string)
+	return name
+}
+
+// HasFocusRules reports whether p is switched to automatically on focus.
+func (p Profile) HasFocusRules() bool {
+	_, class := p[matchClassKey]
+	_, exe := p[matchExeKey]
+	return class || exe
+}
+
+// TriggerKeys returns the keysym names of the profile's trigger keys.
+func (p Profile) TriggerKeys() []string {
+	list, _ := p[triggerKeyKey].([]any)
+	keys := make([]string, 0, len(list))
+	for _, key := range list {
+		if name, ok := key.(string); ok {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// TriggersOn reports whether the profile's trigger keys apply while a window
+// with the given WM_CLASS names and executable is focused: always when the
+// profile has no focus rules, and otherwise only when they match.
+func (p Profile) TriggersOn(class, instance, exe string) bool {
+	return !p.HasFocusRules() || p.MatchesWindow(class, instance, exe)
+}
+
+// MatchesWindow reports whether every focus rule of p matches a window with
+// the given WM_CLASS class and instance names and executable name. The class
+// rule is a regular expression tried against both names; the executable rule
+// is compared with the base name of exe. A profile without rules never matches.
+func (p Profile) MatchesWindow(class, instance, exe string) bool {
+	if !p.HasFocusRules() {
+		return false
+	}
+
+	if pattern, ok := p[matchClassKey].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil || !(re.MatchString(class) || re.MatchString(instance)) {
+			return false
+		}
+	}
+
+	if name, ok := p[matchExeKey].(string); ok {
+		if exe == "" || filepath.Base(exe) != name {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasWatchRules reports whether `gocrosshair watch` starts the overlay with p.
+func (p Profile) HasWatchRules() bool {
+	_, exe := p[watchExeKey]
+	_, cmdline := p[watchCmdKey]
+	return exe || cmdline
+}
+
+// MatchesProcess reports whether every watch rule of p matches a process
+// with the given executable name and command line. The command line rule
+// is a regular expression, which also finds games run through Wine, whose
+// executable is the Wine loader. A profile without rules never matches.
+func (p Profile) MatchesProcess(exe, cmdline string) bool {
+	if !p.HasWatchRules() {
+		return false
+	}
+
+	if name, ok := p[watchExeKey].(string); ok {
+		if exe == "" || filepath.Base(exe) != name {
+			return false
+		}
+	}
+
+	if pattern, ok := p[watchCmdKey].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil || !re.MatchString(cmdline) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasFocusRules reports whether any profile is switched to automatically.
+func (c *Config) HasFocusRules() bool {
+	for _, p := range c.Profiles {
+		if p.HasFocusRules() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTriggerKeys reports whether any profile has trigger keys.
+func (c *Config) HasTriggerKeys() bool {
+	for _, p := range c.Profiles {
+		if len(p.TriggerKeys()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchWindow returns the name of the first profile, in name order, whose
+// focus rules match the window. Profiles with trigger keys are skipped.
+func (c *Config) MatchWindow(class, instance, exe string) (string, bool) {
+	for _, name := range c.ProfileNames() {
+		p := c.Profiles[name]
+		if _, ok := p[triggerKeyKey]; ok {
+			continue
+		}
+		if p.MatchesWindow(class, instance, exe) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// HasWatchRules reports whether any profile has watch rules.
+func (c *Config) HasWatchRules() bool {
+	for _, p := range c.Profiles {
+		if p.HasWatchRules() {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchProcess returns the name of the first profile, in name order, whose
+// watch rules match the process.
+func (c *Config) MatchProcess(exe, cmdline string) (string, bool) {
+	for _, name := range c.ProfileNames() {
+		if c.Profiles[name].MatchesProcess(exe, cmdline) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ProfileNames returns the names of all defined profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the configuration that applies with the named profile
+// active: the base settings with every profile in its extends chain applied,
+// from the outermost ancestor down to the profile itself. An empty name
+// returns a copy of the base settings.
+func (c *Config) Resolve(name string) (*Config, error) {
+	resolved := *c
+	if name == "" {
+		return &resolved, nil
+	}
+
+	chain, err := c.profileChain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if err := c.Profiles[chain[i]].apply(&resolved, chain[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &resolved, nil
+}
+
+// Active resolves the profile chosen at launch, falling back to
+// active_profile when name is empty.
+func (c *Config) Active(name string) (*Config, error) {
+	if name == "" {
+		name = c.ActiveProfile
+	}
+	return c.Resolve(name)
+}
+
+// profileChain returns name followed by each profile it extends, in order.
+func (c *Config) profileChain(name string) ([]string, error) {
+	if _, ok := c.Profiles[name]; !ok {
+		return nil, fmt.Errorf("profile %q is not defined", name)
+	}
+
+	var chain []string
+	seen := make(map[string]bool)
+
+	for current := name; current != ""; current = c.Profiles[current].Extends() {
+		if seen[current] {
+			return nil, &cycleError{name: name, chain: append(chain, current)}
+		}
+		if _, ok := c.Profiles[current]; !ok {
+			return nil, fmt.Errorf("profile %q extends undefined profile %q", chain[len(chain)-1], current)
+		}
+		seen[current] = true
+		chain = append(chain, current)
+	}
+
+	return chain, nil
+}
+
+// cycleError reports an extends chain that leads back into itself. chain
+// starts at the profile being resolved and ends with the first repeat.
+type cycleError struct {
+	name  string
+	chain []string
+}
+
+func (e *cycleError) Error() string {
+	return fmt.Sprintf("profile %q: inheritance cycle %s", e.name, strings.Join(e.chain, " -> "))
+}
+
+// cycle returns the sorted names of the profiles in the loop itself, which
+// are the same whichever member, or profile leading into it, was resolved.
+func (e *cycleError) cycle() string {
+	last := e.chain[len(e.chain)-1]
+	members := slices.Clone(e.chain[slices.Index(e.chain, last) : len(e.chain)-1])
+	sort.Strings(members)
+	return strings.Join(members, ",")
+}
+
+// apply stores the profile's overrides in cfg, checking each one with the
+// field schema.
+func (p Profile) apply(cfg *Config, name string) error {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch key {
+		case extendsKey:
+			if _, ok := p[key].(string); !ok {
+				return fmt.Errorf("profile %q: extends must be a profile name", name)
+			}
+			continue
+		case matchClassKey:
+			pattern, ok := p[key].(string)
+			if !ok {
+				return fmt.Errorf("profile %q: match_class must be a string", name)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("profile %q: invalid match_class: %v", name, err)
+			}
+			continue
+		case matchExeKey:
+			if _, ok := p[key].(string); !ok {
+				return fmt.Errorf("profile %q: match_exe must be a string", name)
+			}
+			continue
+		case watchExeKey:
+			if _, ok := p[key].(string); !ok {
+				return fmt.Errorf("profile %q: watch_exe must be a string", name)
+			}
+			continue
+		case watchCmdKey:
+			pattern, ok := p[key].(string)
+			if !ok {
+				return fmt.Errorf("profile %q: watch_cmdline must be a string", name)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("profile %q: invalid watch_cmdline: %v", name, err)
+			}
+			continue
+		case triggerKeyKey:
+			list, ok := p[key].([]any)
+			if !ok {
+				return fmt.Errorf("profile %q: trigger_keys must be a list of key names", name)
+			}
+			for _, k := range list {
+				keyName, ok := k.(string)
+				if !ok {
+					return fmt.Errorf("profile %q: trigger_keys must be a list of key names", name)
+				}
+				if _, ok := LookupKeysym(keyName); !ok {
+					return fmt.Errorf("profile %q: unknown trigger key %q", name, keyName)
+				}
+			}
+			continue
+		}
+
+		if table, ok := p[key].(map[string]any); ok {
+			subKeys := make([]string, 0, len(table))
+			for sub := range table {
+				subKeys = append(subKeys, sub)
+			}
+			sort.Strings(subKeys)
+			for _, sub := range subKeys {
+				f, ok := LookupSectionField(key, sub)
+				if !ok {
+					return fmt.Errorf("profile %q: unknown setting %q", name, key+"."+sub)
+				}
+				if err := f.Set(cfg, fmt.Sprint(table[sub])); err != nil {
+					return fmt.Errorf("profile %q: %w", name, err)
+				}
+			}
+			continue
+		}
+
+		f, ok := LookupField(key)
+		if !ok {
+			return fmt.Errorf("profile %q: unknown setting %q", name, key)
+		}
+		if err := f.Set(cfg, fmt.Sprint(p[key])); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
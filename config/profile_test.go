@@ -0,0 +1,167 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withProfiles returns the default configuration with the given profiles.
+func withProfiles(profiles map[string]Profile) *Config {
+	cfg := Default()
+	cfg.Profiles = profiles
+	return cfg
+}
+
+func TestResolveChain(t *testing.T) {
+	cfg := withProfiles(map[string]Profile{
+		"rifle":  {"color": "#FF0000", "size": int64(8), "hitmarker.size": int64(12)},
+		"sniper": {"extends": "rifle", "shape": "dot", "size": int64(3)},
+		"scope":  {"extends": "sniper", "hitmarker": map[string]any{"enabled": true}},
+	})
+	cfg.Crosshair.Gap = 7
+
+	got, err := cfg.Resolve("scope")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The closest profile wins, the rest is inherited down the chain.
+	if got.Crosshair.Shape != "dot" || got.Crosshair.Size != 3 || got.Crosshair.Color != "#FF0000" {
+		t.Errorf("crosshair = %+v, want the sniper's shape and size with the rifle's color", got.Crosshair)
+	}
+	if got.Hitmarker.Size != 12 || !got.Hitmarker.Enabled {
+		t.Errorf("hitmarker = %+v, want size 12 and enabled", got.Hitmarker)
+	}
+
+	// Settings no profile sets keep their base values.
+	if got.Crosshair.Gap != 7 || got.Crosshair.Thickness != cfg.Crosshair.Thickness {
+		t.Errorf("gap, thickness = %d, %d, want the base %d, %d",
+			got.Crosshair.Gap, got.Crosshair.Thickness, 7, cfg.Crosshair.Thickness)
+	}
+	if !reflect.DeepEqual(got.Position, cfg.Position) {
+		t.Errorf("position = %+v, want the base %+v", got.Position, cfg.Position)
+	}
+
+	// The base configuration is not changed.
+	if cfg.Crosshair.Shape != DefaultShape || cfg.Hitmarker.Size != DefaultHitmarkerSize {
+		t.Errorf("base changed to %+v, %+v", cfg.Crosshair, cfg.Hitmarker)
+	}
+}
+
+func TestResolveBase(t *testing.T) {
+	cfg := withProfiles(map[string]Profile{"rifle": {"size": int64(8)}})
+
+	got, err := cfg.Resolve("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == cfg || got.Crosshair != cfg.Crosshair {
+		t.Errorf("Resolve(\"\") = %+v, want a copy of the base settings", got.Crosshair)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles map[string]Profile
+		resolve  string
+		want     string
+	}{
+		{
+			name:     "not defined",
+			profiles: map[string]Profile{},
+			resolve:  "rifle",
+			want:     `profile "rifle" is not defined`,
+		},
+		{
+			name:     "undefined parent",
+			profiles: map[string]Profile{"sniper": {"extends": "rifle"}},
+			resolve:  "sniper",
+			want:     `profile "sniper" extends undefined profile "rifle"`,
+		},
+		{
+			name: "cycle",
+			profiles: map[string]Profile{
+				"a": {"extends": "b"},
+				"b": {"extends": "a"},
+				"c": {"extends": "a"},
+			},
+			resolve: "c",
+			want:    `profile "c": inheritance cycle c -> a -> b -> a`,
+		},
+		{
+			name:     "extends itself",
+			profiles: map[string]Profile{"a": {"extends": "a"}},
+			resolve:  "a",
+			want:     `profile "a": inheritance cycle a -> a`,
+		},
+		{
+			name:     "value out of range",
+			profiles: map[string]Profile{"rifle": {"size": int64(900)}},
+			resolve:  "rifle",
+			want:     `profile "rifle": size must be between 1 and 500 (got 900)`,
+		},
+		{
+			name:     "bad value in a parent",
+			profiles: map[string]Profile{"rifle": {"shape": "star"}, "sniper": {"extends": "rifle"}},
+			resolve:  "sniper",
+			want:     `profile "rifle": invalid shape "star"`,
+		},
+		{
+			name:     "bad value in a table",
+			profiles: map[string]Profile{"rifle": {"idle": map[string]any{"hide_on_lock": "often"}}},
+			resolve:  "rifle",
+			want:     `profile "rifle": idle.hide_on_lock must be true or false`,
+		},
+		{
+			name:     "unknown setting",
+			profiles: map[string]Profile{"rifle": {"hitmarker": map[string]any{"monitor": int64(1)}}},
+			resolve:  "rifle",
+			want:     `profile "rifle": unknown setting "hitmarker.monitor"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := withProfiles(tt.profiles).Resolve(tt.resolve)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Resolve(%q) error = %v, want %q", tt.resolve, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsCycleOnce(t *testing.T) {
+	cfg := withProfiles(map[string]Profile{
+		"a": {"extends": "b"},
+		"b": {"extends": "c"},
+		"c": {"extends": "a"},
+		"d": {"extends": "a"},
+		"e": {"extends": "e"},
+	})
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted inheritance cycles")
+	}
+	if n := strings.Count(err.Error(), "inheritance cycle"); n != 2 {
+		t.Errorf("Validate reported %d cycles, want one for a, b, c and one for e:\n%v", n, err)
+	}
+}
+
+func TestValidateReportsBrokenParentOnce(t *testing.T) {
+	cfg := withProfiles(map[string]Profile{
+		"rifle":  {"size": int64(900)},
+		"sniper": {"extends": "rifle"},
+		"scope":  {"extends": "sniper"},
+	})
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted an out of range profile value")
+	}
+	if n := strings.Count(err.Error(), "size must be between"); n != 1 {
+		t.Errorf("Validate reported the bad size %d times, want once:\n%v", n, err)
+	}
+}
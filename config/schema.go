@@ -0,0 +1,400 @@
+// Package config handles configuration loading, saving, and validation for gocrosshair.
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldKind describes how a field's value is entered and parsed.
+type FieldKind int
+
+const (
+	// KindChoice is a string restricted to a fixed list of options.
+	KindChoice FieldKind = iota
+	// KindColor is a hex color accepted by ParseColor.
+	KindColor
+	// KindInt is an integer within [Min, Max].
+	KindInt
+	// KindPattern is a regular expression, or empty to leave it unused.
+	KindPattern
+	// KindBool is true or false.
+	KindBool
+)
+
+// Field describes a single configuration setting. The schema is shared by
+// Validate, the setup wizard and the non-interactive setup mode so that
+// prompts, ranges and defaults cannot disagree.
+type Field struct {
+	Key     string // TOML key within Section
+	Section string // TOML table the key lives in
+	Label   string // Short human-readable name
+	Prompt  string // Question asked by the setup wizard
+	Kind    FieldKind
+	Min     int      // Lowest accepted value for KindInt
+	Max     int      // Highest accepted value for KindInt
+	Unit    string   // Unit shown after KindInt values, if any
+	Choices []string // Accepted values for KindChoice
+
+	str  func(*Config) *string
+	num  func(*Config) *int
+	flag func(*Config) *bool
+}
+
+// Fields lists the settings that take a single value, starting with the ones
+// the wizard asks for, in order. Lists such as the movement keys, and
+// settings checked against other ones such as mouse.aim, are checked by
+// Validate directly.
+var Fields = []Field{
+	{
+		Key: "shape", Section: "crosshair", Label: "Shape",
+		Prompt: "Select crosshair shape:",
+		Kind:   KindChoice, Choices: ValidShapes,
+		str: func(c *Config) *string { return &c.Crosshair.Shape },
+	},
+	{
+		Key: "color", Section: "crosshair", Label: "Color",
+		Prompt: "Select crosshair color:",
+		Kind:   KindColor,
+		str:    func(c *Config) *string { return &c.Crosshair.Color },
+	},
+	{
+		Key: "size", Section: "crosshair", Label: "Size",
+		Prompt: "Crosshair size (pixels from center):",
+		Kind:   KindInt, Min: 1, Max: 500, Unit: "px",
+		num: func(c *Config) *int { return &c.Crosshair.Size },
+	},
+	{
+		Key: "thickness", Section: "crosshair", Label: "Thickness",
+		Prompt: "Line thickness (pixels):",
+		Kind:   KindInt, Min: 1, Max: 100, Unit: "px",
+		num: func(c *Config) *int { return &c.Crosshair.Thickness },
+	},
+	{
+		Key: "gap", Section: "crosshair", Label: "Gap",
+		Prompt: "Center gap (0 for solid, or pixels):",
+		Kind:   KindInt, Min: 0, Max: 100, Unit: "px",
+		num: func(c *Config) *int { return &c.Crosshair.Gap },
+	},
+	{
+		Key: "outline_thickness", Section: "crosshair", Label: "Outline",
+		Prompt: "Outline thickness (0 for no outline, or pixels):",
+		Kind:   KindInt, Min: 0, Max: 50, Unit: "px",
+		num: func(c *Config) *int { return &c.Crosshair.OutlineThickness },
+	},
+	{
+		Key: "outline_color", Section: "crosshair", Label: "Outline color",
+		Prompt: "Select outline color:",
+		Kind:   KindColor,
+		str:    func(c *Config) *string { return &c.Crosshair.OutlineColor },
+	},
+	{
+		Key: "monitor", Section: "position", Label: "Monitor",
+		Prompt: "Select monitor:",
+		Kind:   KindInt, Min: -1, Max: 100,
+		num: func(c *Config) *int { return &c.Position.Monitor },
+	},
+	{
+		Key: "offset_x", Section: "position", Label: "Offset X",
+		Prompt: "Horizontal offset from center (pixels, negative=left):",
+		Kind:   KindInt, Min: -10000, Max: 10000, Unit: "px",
+		num: func(c *Config) *int { return &c.Position.OffsetX },
+	},
+	{
+		Key: "offset_y", Section: "position", Label: "Offset Y",
+		Prompt: "Vertical offset from center (pixels, negative=up):",
+		Kind:   KindInt, Min: -10000, Max: 10000, Unit: "px",
+		num: func(c *Config) *int { return &c.Position.OffsetY },
+	},
+	{
+		Key: "anchor", Section: "position", Label: "Anchor",
+		Prompt: "Center the crosshair on:",
+		Kind:   KindChoice, Choices: ValidAnchors,
+		str: func(c *Config) *string { return &c.Position.Anchor },
+	},
+	{
+		Key: "window_class", Section: "position", Label: "Window class",
+		Prompt: "Regular expression for the game window's WM_CLASS (empty to ignore):",
+		Kind:   KindPattern,
+		str:    func(c *Config) *string { return &c.Position.WindowClass },
+	},
+	{
+		Key: "window_title", Section: "position", Label: "Window title",
+		Prompt: "Regular expression for the game window's title (empty to ignore):",
+		Kind:   KindPattern,
+		str:    func(c *Config) *string { return &c.Position.WindowTitle },
+	},
+	{
+		Key: "visibility", Section: "position", Label: "Visibility",
+		Prompt: "When should the crosshair be shown?",
+		Kind:   KindChoice, Choices: ValidVisibilities,
+		str: func(c *Config) *string { return &c.Position.Visibility },
+	},
+	{
+		Key: "enabled", Section: "hitmarker", Label: "Hitmarker",
+		Kind: KindBool,
+		flag: func(c *Config) *bool { return &c.Hitmarker.Enabled },
+	},
+	{
+		Key: "color", Section: "hitmarker", Label: "Hitmarker color",
+		Kind: KindColor,
+		str:  func(c *Config) *string { return &c.Hitmarker.Color },
+	},
+	{
+		Key: "size", Section: "hitmarker", Label: "Hitmarker size",
+		Kind: KindInt, Min: 1, Max: 100, Unit: "px",
+		num: func(c *Config) *int { return &c.Hitmarker.Size },
+	},
+	{
+		Key: "gap", Section: "hitmarker", Label: "Hitmarker gap",
+		Kind: KindInt, Min: 0, Max: 100, Unit: "px",
+		num: func(c *Config) *int { return &c.Hitmarker.Gap },
+	},
+	{
+		Key: "thickness", Section: "hitmarker", Label: "Hitmarker thickness",
+		Kind: KindInt, Min: 1, Max: 20, Unit: "px",
+		num: func(c *Config) *int { return &c.Hitmarker.Thickness },
+	},
+	{
+		Key: "duration_ms", Section: "hitmarker", Label: "Hitmarker duration",
+		Kind: KindInt, Min: 10, Max: 2000, Unit: "ms",
+		num: func(c *Config) *int { return &c.Hitmarker.Duration },
+	},
+	{
+		Key: "fade_steps", Section: "hitmarker", Label: "Hitmarker fade steps",
+		Kind: KindInt, Min: 1, Max: 20,
+		num: func(c *Config) *int { return &c.Hitmarker.FadeSteps },
+	},
+	{
+		Key: "spread", Section: "mouse", Label: "Firing spread",
+		Kind: KindInt, Min: 0, Max: maxSpread, Unit: "px",
+		num: func(c *Config) *int { return &c.Mouse.Spread },
+	},
+	{
+		Key: "spread_ramp_ms", Section: "mouse", Label: "Firing spread ramp",
+		Kind: KindInt, Min: 0, Max: maxDuration, Unit: "ms",
+		num: func(c *Config) *int { return &c.Mouse.SpreadRamp },
+	},
+	{
+		Key: "spread_recover_ms", Section: "mouse", Label: "Firing spread recovery",
+		Kind: KindInt, Min: 0, Max: maxDuration, Unit: "ms",
+		num: func(c *Config) *int { return &c.Mouse.SpreadRecover },
+	},
+	{
+		Key: "spread", Section: "movement", Label: "Movement spread",
+		Kind: KindInt, Min: 0, Max: maxSpread, Unit: "px",
+		num: func(c *Config) *int { return &c.Movement.Spread },
+	},
+	{
+		Key: "spread_ramp_ms", Section: "movement", Label: "Movement spread ramp",
+		Kind: KindInt, Min: 0, Max: maxDuration, Unit: "ms",
+		num: func(c *Config) *int { return &c.Movement.SpreadRamp },
+	},
+	{
+		Key: "spread_recover_ms", Section: "movement", Label: "Movement spread recovery",
+		Kind: KindInt, Min: 0, Max: maxDuration, Unit: "ms",
+		num: func(c *Config) *int { return &c.Movement.SpreadRecover },
+	},
+	{
+		Key: "curve", Section: "movement", Label: "Movement spread curve",
+		Kind: KindChoice, Choices: ValidCurves,
+		str: func(c *Config) *string { return &c.Movement.Curve },
+	},
+	{
+		Key: "auto_hide", Section: "cursor", Label: "Hide with cursor",
+		Kind: KindBool,
+		flag: func(c *Config) *bool { return &c.Cursor.AutoHide },
+	},
+	{
+		Key: "debounce_ms", Section: "cursor", Label: "Cursor debounce",
+		Kind: KindInt, Min: 0, Max: maxDuration, Unit: "ms",
+		num: func(c *Config) *int { return &c.Cursor.Debounce },
+	},
+	{
+		Key: "hide_after_s", Section: "idle", Label: "Hide when idle",
+		Kind: KindInt, Min: 0, Max: maxIdle, Unit: "s",
+		num: func(c *Config) *int { return &c.Idle.HideAfter },
+	},
+	{
+		Key: "hide_on_lock", Section: "idle", Label: "Hide when locked",
+		Kind: KindBool,
+		flag: func(c *Config) *bool { return &c.Idle.HideOnLock },
+	},
+}
+
+// Name returns the name the field goes by in --set, answers files, profiles
+// and error messages: the bare key for the [crosshair] and [position]
+// tables, which the wizard edits, and "section.key" for the other tables.
+func (f Field) Name() string {
+	if f.Section == "crosshair" || f.Section == "position" {
+		return f.Key
+	}
+	return f.Section + "." + f.Key
+}
+
+// LookupField returns the field with the given name.
+func LookupField(name string) (Field, bool) {
+	for _, f := range Fields {
+		if f.Name() == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// LookupSectionField returns the field with the given key in a TOML table.
+func LookupSectionField(section, key string) (Field, bool) {
+	for _, f := range Fields {
+		if f.Section == section && f.Key == key {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// FieldNames returns the names of all fields in schema order.
+func FieldNames() []string {
+	names := make([]string, len(Fields))
+	for i, f := range Fields {
+		names[i] = f.Name()
+	}
+	return names
+}
+
+// Value returns the field's current value in cfg, formatted as it would be
+// written in TOML or passed to Set.
+func (f Field) Value(cfg *Config) string {
+	switch f.Kind {
+	case KindInt:
+		return strconv.Itoa(*f.num(cfg))
+	case KindBool:
+		return strconv.FormatBool(*f.flag(cfg))
+	default:
+		return *f.str(cfg)
+	}
+}
+
+// Int returns the field's current value in cfg. It is only meaningful for KindInt fields.
+func (f Field) Int(cfg *Config) int {
+	if f.num == nil {
+		return 0
+	}
+	return *f.num(cfg)
+}
+
+// Default returns the field's default value, formatted like Value.
+func (f Field) Default() string {
+	return f.Value(Default())
+}
+
+// Range describes the accepted values for prompts and error messages.
+func (f Field) Range() string {
+	switch f.Kind {
+	case KindChoice:
+		return strings.Join(f.Choices, ", ")
+	case KindColor:
+		return "#RRGGBB, 0xRRGGBB or RRGGBB"
+	case KindPattern:
+		return "a regular expression"
+	case KindBool:
+		return "true or false"
+	default:
+		return fmt.Sprintf("%d to %d", f.Min, f.Max)
+	}
+}
+
+// Parse checks raw against the field's rules and returns the value that Set
+// would store. Colors are normalized to #RRGGBB.
+func (f Field) Parse(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+
+	switch f.Kind {
+	case KindChoice:
+		if !slices.Contains(f.Choices, raw) {
+			return "", fmt.Errorf("invalid %s %q (must be one of: %s)", f.Name(), raw, f.Range())
+		}
+		return raw, nil
+
+	case KindColor:
+		val, err := ParseColor(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s %q: %v", f.Name(), raw, err)
+		}
+		return fmt.Sprintf("#%06X", val), nil
+
+	case KindPattern:
+		if _, err := regexp.Compile(raw); err != nil {
+			return "", fmt.Errorf("invalid %s %q: %v", f.Name(), raw, err)
+		}
+		return raw, nil
+
+	case KindBool:
+		val, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "", fmt.Errorf("%s must be true or false (got %q)", f.Name(), raw)
+		}
+		return strconv.FormatBool(val), nil
+
+	default:
+		val, err := strconv.Atoi(raw)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a whole number (got %q)", f.Name(), raw)
+		}
+		if err := f.checkInt(val); err != nil {
+			return "", err
+		}
+		return strconv.Itoa(val), nil
+	}
+}
+
+// Set parses raw and stores it in cfg. cfg is left unchanged on error.
+func (f Field) Set(cfg *Config, raw string) error {
+	val, err := f.Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	switch f.Kind {
+	case KindInt:
+		*f.num(cfg), _ = strconv.Atoi(val)
+	case KindBool:
+		*f.flag(cfg), _ = strconv.ParseBool(val)
+	default:
+		*f.str(cfg) = val
+	}
+	return nil
+}
+
+// Check validates the field's current value in cfg.
+func (f Field) Check(cfg *Config) error {
+	switch f.Kind {
+	case KindChoice:
+		if val := f.Value(cfg); !slices.Contains(f.Choices, val) {
+			return fmt.Errorf("invalid %s %q (must be one of: %s)", f.Name(), val, f.Range())
+		}
+	case KindColor:
+		val := f.Value(cfg)
+		if _, err := ParseColor(val); err != nil {
+			return fmt.Errorf("invalid %s %q: %v", f.Name(), val, err)
+		}
+	case KindPattern:
+		val := f.Value(cfg)
+		if _, err := regexp.Compile(val); err != nil {
+			return fmt.Errorf("invalid %s %q: %v", f.Name(), val, err)
+		}
+	case KindInt:
+		return f.checkInt(f.Int(cfg))
+	}
+	return nil
+}
+
+// checkInt reports whether val is within the field's range.
+func (f Field) checkInt(val int) error {
+	if val < f.Min || val > f.Max {
+		return fmt.Errorf("%s must be between %d and %d (got %d)", f.Name(), f.Min, f.Max, val)
+	}
+	return nil
+}
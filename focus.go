@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log"
+
+	"github.com/jezek/xgb/xproto"
+
+	"gocrosshair/compositor"
+	"gocrosshair/config"
+	"gocrosshair/overlay"
+)
+
+// focusSwitcher switches the overlay to the profile whose focus rules match
+// the focused window.
+type focusSwitcher struct {
+	session *session
+	launch  string // Profile chosen at launch, "" for the base settings
+}
+
+// focusChanged is called by the overlay whenever another window is focused.
+func (s *focusSwitcher) focusChanged(w overlay.FocusedWindow) {
+	s.session.mu.Lock()
+	defer s.session.mu.Unlock()
+
+	s.session.focused = w
+
+	name, matched := s.session.base.MatchWindow(w.Class, w.Instance, w.Exe)
+
+	hide := false
+	if !matched {
+		switch unmatched := s.session.base.Focus.Unmatched; unmatched {
+		case "", config.UnmatchedDefault:
+			name = s.launch
+		case config.UnmatchedHide:
+			hide = true
+		default:
+			name = unmatched
+		}
+	}
+
+	if err := s.session.overlay.SetHidden(overlay.HideUnmatched, hide); err != nil {
+		log.Printf("Warning: failed to update crosshair visibility: %v", err)
+	}
+	if hide || name == s.session.profile {
+		return
+	}
+
+	if err := s.session.use(name); err != nil {
+		log.Printf("Warning: failed to apply profile %q: %v", name, err)
+		return
+	}
+
+	if matched {
+		log.Printf("Focused %s (%s): using profile %q", w.Class, w.Exe, name)
+	}
+}
+
+// watchCompositor follows the focus through the compositor's IPC socket in
+// the background, driving fullscreen-only mode and, when switcher is not
+// nil, profile switching. It reports false when no compositor IPC was
+// found, so the caller can follow the focus through X instead. If the IPC
+// connection is lost later, the focus is followed through X from then on.
+func watchCompositor(o *overlay.Overlay, switcher *focusSwitcher) bool {
+	client, err := compositor.Detect()
+	if err != nil {
+		log.Printf("Warning: %v, following the focus through X", err)
+		return false
+	}
+
+	go func() {
+		err := client.Watch(func(w compositor.Window) {
+			o.SetFocusedFullscreen(w.Fullscreen, xproto.Rectangle{
+				X:      int16(w.Area.X),
+				Y:      int16(w.Area.Y),
+				Width:  uint16(max(w.Area.Width, 0)),
+				Height: uint16(max(w.Area.Height, 0)),
+			})
+			if switcher == nil {
+				return
+			}
+
+			// i3 reports no process for X windows, only their ID.
+			pid := w.PID
+			if pid == 0 && w.XID != 0 {
+				pid = o.WindowPID(xproto.Window(w.XID))
+			}
+			switcher.focusChanged(overlay.FocusedWindow{
+				ID:       xproto.Window(w.XID),
+				Class:    w.Class,
+				Instance: w.Instance,
+				PID:      pid,
+				Exe:      overlay.ProcessExe(pid),
+			})
+		})
+		log.Printf("Warning: lost the %s IPC connection: %v, following the focus through X", client.Name(), err)
+
+		o.ClearFocusedFullscreen()
+		watchFocusX(o, switcher)
+	}()
+
+	log.Printf("Following the focus through %s IPC", client.Name())
+	return true
+}
+
+// watchFocusX follows the focus through _NET_ACTIVE_WINDOW for profile
+// switching, when switcher is not nil.
+func watchFocusX(o *overlay.Overlay, switcher *focusSwitcher) {
+	if switcher == nil {
+		return
+	}
+	if err := o.WatchFocus(switcher.focusChanged); err != nil {
+		log.Printf("Warning: automatic profile switching unavailable: %v", err)
+	}
+}
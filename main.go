@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/jezek/xgb"
@@ -37,7 +38,7 @@ func daemonize() bool {
 		return false
 	}
 
-	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd := exec.Command(os.Args[0], daemonArgs(os.Args[1:])...)
 	cmd.Env = append(os.Environ(), daemonEnvVar+"=1")
 	cmd.Stdin = nil
 	cmd.Stdout = nil
@@ -55,6 +56,34 @@ func daemonize() bool {
 	return true
 }
 
+// daemonArgs returns the arguments for the background process. The setup
+// flag is dropped because the wizard has already run in the foreground.
+func daemonArgs(args []string) []string {
+	var out []string
+	for _, arg := range args {
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == "setup" {
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// waitForDaemon waits until the background process has written its PID file,
+// so a live preview can be closed without the crosshair blinking off.
+func waitForDaemon(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if pid, err := readPIDFile(); err == nil && isProcessRunning(pid) {
+			// Give the new process a moment to map its window.
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 // getPIDFilePath returns the path to the PID file.
 func getPIDFilePath() string {
 	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
@@ -124,25 +153,60 @@ func stopRunningInstance() error {
 }
 
 // runSetupWizard runs the interactive configuration wizard.
-// Returns true if user wants to start the crosshair after setup.
-func runSetupWizard(cfgPath string) bool {
+// Returns true if user wants to start the crosshair after setup, along with
+// the live preview overlay that is still on screen, if any.
+func runSetupWizard(cfgPath string) (bool, *overlay.Overlay) {
 	monitors, err := getMonitorsForWizard()
 	if err != nil {
 		log.Printf("Warning: could not detect monitors: %v", err)
 	}
 
 	model := wizard.NewModel(monitors, cfgPath)
+	model = model.WithLivePreview(startLivePreview(model.GetConfig()))
 	p := tea.NewProgram(model)
 
 	finalModel, err := p.Run()
 	if err != nil {
+		if preview := model.LivePreview(); preview != nil {
+			preview.Close()
+		}
 		log.Fatalf("Error running setup wizard: %v", err)
 	}
 
 	if m, ok := finalModel.(wizard.Model); ok && m.WantsToStart() {
-		return true
+		return true, m.LivePreview()
+	}
+	return false, nil
+}
+
+// startLivePreview opens an overlay that the wizard redraws as values change.
+// Returns nil if the X server is unavailable; the wizard then runs without it.
+func startLivePreview(cfg *config.Config) *overlay.Overlay {
+	preview := *cfg
+	preview.Position.Visibility = config.VisibilityAlways
+	preview.Position.Desktops = nil
+	preview.Cursor.AutoHide = false
+	preview.Idle.HideAfter = 0
+
+	o, err := overlay.NewOverlay(&preview)
+	if err != nil {
+		log.Printf("Warning: live preview unavailable: %v", err)
+		return nil
+	}
+
+	if err := o.Start(); err != nil {
+		log.Printf("Warning: live preview unavailable: %v", err)
+		o.Close()
+		return nil
 	}
-	return false
+
+	go func() {
+		if err := o.ProcessEvents(); err != nil {
+			log.Printf("Warning: live preview stopped: %v", err)
+		}
+	}()
+
+	return o
 }
 
 // getMonitorsForWizard connects to X and retrieves monitor info for the wizard.
@@ -166,6 +230,8 @@ func getMonitorsForWizard() ([]wizard.Monitor, error) {
 		wizardMonitors[i] = wizard.Monitor{
 			Index:   i,
 			Name:    m.Name,
+			X:       m.X,
+			Y:       m.Y,
 			Width:   m.Width,
 			Height:  m.Height,
 			Primary: m.Primary,
@@ -176,15 +242,41 @@ func getMonitorsForWizard() ([]wizard.Monitor, error) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := runRender(os.Args[2:]); err != nil {
+			if err == flag.ErrHelp {
+				os.Exit(0)
+			}
+			log.Fatalf("Error: %v", err)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		if err := runWatch(os.Args[2:]); err != nil {
+			if err == flag.ErrHelp {
+				os.Exit(0)
+			}
+			log.Fatalf("Error: %v", err)
+		}
+		os.Exit(0)
+	}
+
 	configPath := flag.String("config", "", "Path to configuration file (default: ~/.config/gocrosshair/config.toml)")
+	profile := flag.String("profile", "", "Profile to use (default: active_profile from the config)")
 	listMonitors := flag.Bool("list-monitors", false, "List available monitors and exit")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	stopInstance := flag.Bool("stop", false, "Stop any running gocrosshair instance")
 	runSetup := flag.Bool("setup", false, "Run interactive setup wizard to create configuration")
+	answersPath := flag.String("answers", "", "With -setup: read answers from a TOML file instead of prompting")
+	var sets repeatedFlag
+	flag.Var(&sets, "set", "With -setup: set a field without prompting, as key=value (repeatable)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "gocrosshair - Lightweight crosshair overlay for X11/XWayland\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s render --out FILE [--scale N] [--background checker]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s watch [--interval 2s]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nConfiguration file location:\n")
@@ -218,8 +310,23 @@ func main() {
 		cfgPath = config.GetConfigPath()
 	}
 
+	if *runSetup && (*answersPath != "" || len(sets) > 0) {
+		os.Exit(runScriptedSetup(cfgPath, *answersPath, sets))
+	}
+
+	// Check the profile before going to the background, where errors are not shown.
+	if *profile != "" {
+		if cfg, err := config.LoadValid(cfgPath); err == nil {
+			if _, err := cfg.Active(*profile); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+	}
+
+	var preview *overlay.Overlay
 	if *runSetup {
-		if !runSetupWizard(cfgPath) {
+		var start bool
+		if start, preview = runSetupWizard(cfgPath); !start {
 			os.Exit(0)
 		}
 	}
@@ -235,6 +342,11 @@ func main() {
 	}
 
 	if daemonize() {
+		// Keep the preview on screen until the background process takes over.
+		if preview != nil {
+			waitForDaemon(2 * time.Second)
+			preview.Close()
+		}
 		os.Exit(0)
 	}
 
@@ -261,12 +373,55 @@ func main() {
 		}
 	}
 
+	base := cfg
+	cfg, err = base.Active(*profile)
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+
 	o, err := overlay.NewOverlay(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create overlay: %v", err)
 	}
 	defer o.Close()
 
+	launch := *profile
+	if launch == "" {
+		launch = base.ActiveProfile
+	}
+	sess := newSession(o, base, launch, cfg)
+
+	// Trigger keys are limited to the windows their profile matches.
+	var switcher *focusSwitcher
+	if base.HasFocusRules() || base.HasTriggerKeys() {
+		switcher = &focusSwitcher{session: sess, launch: launch}
+	}
+	if !base.Focus.IPC || !watchCompositor(o, switcher) {
+		watchFocusX(o, switcher)
+	}
+
+	if syms := triggerKeys(base); len(syms) > 0 {
+		if err := o.WatchKeyPresses(syms, sess.triggerKey); err != nil {
+			log.Printf("Warning: trigger keys unavailable: %v", err)
+		}
+	}
+
+	if base.Mouse.WatchesButtons() {
+		o.WatchButtons(sess.buttons)
+	}
+
+	if keys := bindings(base); len(keys) > 0 {
+		bound, err := o.GrabKeys(keys, sess.hotkey)
+		if err != nil {
+			log.Printf("Warning: hotkeys unavailable: %v", err)
+		}
+		if bound[config.ActionHold] {
+			// Hold-to-show starts hidden until the key is pressed. If the key
+			// could not be grabbed, the crosshair stays visible instead.
+			_ = o.SetHidden(overlay.HideReleased, true)
+		}
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
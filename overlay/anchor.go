@@ -0,0 +1,173 @@
+package overlay
+
+import (
+	"encoding/binary"
+	"log"
+	"regexp"
+
+	"github.com/jezek/xgb/xproto"
+
+	"gocrosshair/config"
+)
+
+// clientEventMask is selected on other clients' windows the overlay follows.
+const clientEventMask = xproto.EventMaskPropertyChange | xproto.EventMaskStructureNotify
+
+// updateCenter places the crosshair at the pointer in pointer anchor mode, at
+// the anchor window's center when one is being followed, and at the selected
+// monitor's center otherwise, plus the configured offsets. The caller must
+// hold o.mu.
+func (o *Overlay) updateCenter() {
+	x, y := o.monitor.CenterX(), o.monitor.CenterY()
+	switch {
+	case o.followsPointer() && o.pointerKnown:
+		x, y = o.pointerX, o.pointerY
+	case o.anchorWindow != 0:
+		x = o.anchorRect.X + int16(o.anchorRect.Width/2)
+		y = o.anchorRect.Y + int16(o.anchorRect.Height/2)
+	}
+	o.centerX = x + int16(o.config.Position.OffsetX)
+	o.centerY = y + int16(o.config.Position.OffsetY)
+}
+
+// updateAnchor finds the window to center on in window anchor mode and
+// starts following it. Until a matching window exists the crosshair stays
+// at the monitor's center. The caller must hold o.mu.
+func (o *Overlay) updateAnchor() {
+	id := xproto.Window(0)
+	if o.config.Position.Anchor == config.AnchorWindow {
+		id = o.findAnchorWindow()
+	}
+
+	if id != o.anchorWindow {
+		o.anchorWindow = id
+		o.anchorFrame = 0
+		if id != 0 {
+			o.anchorFrame = o.topLevel(id)
+			log.Printf("Anchored crosshair to window 0x%x", id)
+		}
+		o.updateWatches()
+	}
+
+	o.updateAnchorGeometry()
+}
+
+// updateAnchorGeometry reads the anchor window's client area in root
+// coordinates and recomputes the center. The caller must hold o.mu.
+func (o *Overlay) updateAnchorGeometry() {
+	if o.anchorWindow != 0 {
+		geom, err := xproto.GetGeometry(o.conn, xproto.Drawable(o.anchorWindow)).Reply()
+		if err == nil {
+			pos, err := xproto.TranslateCoordinates(o.conn, o.anchorWindow, o.screen.Root, 0, 0).Reply()
+			if err == nil {
+				o.anchorRect = xproto.Rectangle{X: pos.DstX, Y: pos.DstY, Width: geom.Width, Height: geom.Height}
+			}
+		}
+	}
+	o.updateCenter()
+}
+
+// findAnchorWindow returns the first window in the window manager's client
+// list whose WM_CLASS matches window_class and whose title matches
+// window_title. Empty patterns match any window.
+func (o *Overlay) findAnchorWindow() xproto.Window {
+	classRe, err := regexp.Compile(o.config.Position.WindowClass)
+	if err != nil {
+		return 0
+	}
+	titleRe, err := regexp.Compile(o.config.Position.WindowTitle)
+	if err != nil {
+		return 0
+	}
+
+	reply, err := xproto.GetProperty(o.conn, false, o.screen.Root, o.atoms["_NET_CLIENT_LIST"],
+		xproto.AtomWindow, 0, 4096).Reply()
+	if err != nil || reply.Format != 32 {
+		return 0
+	}
+
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		id := xproto.Window(binary.LittleEndian.Uint32(reply.Value[i:]))
+		w := o.describeWindow(id)
+		if !classRe.MatchString(w.Class) && !classRe.MatchString(w.Instance) {
+			continue
+		}
+		if !titleRe.MatchString(o.windowTitle(id)) {
+			continue
+		}
+		return id
+	}
+
+	return 0
+}
+
+// windowTitle returns _NET_WM_NAME, falling back to WM_NAME.
+func (o *Overlay) windowTitle(id xproto.Window) string {
+	reply, err := xproto.GetProperty(o.conn, false, id, o.atoms["_NET_WM_NAME"],
+		o.atoms["UTF8_STRING"], 0, 1024).Reply()
+	if err == nil && reply.Format == 8 && len(reply.Value) > 0 {
+		return string(reply.Value)
+	}
+
+	reply, err = xproto.GetProperty(o.conn, false, id, xproto.AtomWmName,
+		xproto.GetPropertyTypeAny, 0, 1024).Reply()
+	if err == nil && reply.Format == 8 {
+		return string(reply.Value)
+	}
+	return ""
+}
+
+// topLevel returns the child of the root window that contains id: the window
+// manager's frame around it, or id itself when it is not reparented.
+// Moving the frame does not send ConfigureNotify to the client, so both are
+// followed.
+func (o *Overlay) topLevel(id xproto.Window) xproto.Window {
+	for {
+		tree, err := xproto.QueryTree(o.conn, id).Reply()
+		if err != nil || tree.Parent == 0 {
+			return id
+		}
+		if tree.Parent == o.screen.Root {
+			return id
+		}
+		id = tree.Parent
+	}
+}
+
+// updateWatches selects property and structure events on every other
+// client's window the overlay follows, and deselects them on windows it no
+// longer follows. The caller must hold o.mu.
+func (o *Overlay) updateWatches() {
+	want := make(map[xproto.Window]bool)
+	for _, id := range []xproto.Window{o.activeWindow, o.anchorWindow, o.anchorFrame} {
+		if id != 0 && id != o.screen.Root && id != o.windowID {
+			want[id] = true
+		}
+	}
+
+	// The windows may already be gone, so errors are ignored. Requests are
+	// checked so that errors do not reach the event loop.
+	for id := range o.watched {
+		if !want[id] {
+			_ = xproto.ChangeWindowAttributesChecked(o.conn, id, xproto.CwEventMask,
+				[]uint32{xproto.EventMaskNoEvent}).Check()
+		}
+	}
+	for id := range want {
+		if !o.watched[id] {
+			_ = xproto.ChangeWindowAttributesChecked(o.conn, id, xproto.CwEventMask,
+				[]uint32{clientEventMask}).Check()
+		}
+	}
+
+	o.watched = want
+}
+
+// reposition moves the crosshair after its center changed.
+// The caller must hold o.mu.
+func (o *Overlay) reposition(oldX, oldY int16) {
+	if o.centerX == oldX && o.centerY == oldY {
+		return
+	}
+	o.redraw()
+}
@@ -0,0 +1,42 @@
+package overlay
+
+import (
+	"github.com/jezek/xgb/xproto"
+)
+
+// Buttons records which of the mouse buttons the crosshair reacts to are held.
+type Buttons struct {
+	Fire bool // Left button
+	Aim  bool // Right button
+}
+
+// buttonsFromMask reads the buttons from the state QueryPointer reports.
+func buttonsFromMask(mask uint16) Buttons {
+	return Buttons{
+		Fire: mask&xproto.KeyButMaskButton1 != 0,
+		Aim:  mask&xproto.KeyButMaskButton3 != 0,
+	}
+}
+
+// WatchButtons calls fn whenever the left or right mouse button is pressed
+// or released. The buttons are followed through the RECORD extension, which
+// does not take them away from other clients the way a grab would. fn runs
+// on the goroutine reading the events, without the overlay lock held, so it
+// may call Apply or SetHidden.
+func (o *Overlay) WatchButtons(fn func(Buttons)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.onButtons = fn
+	o.updateInput()
+}
+
+// updateButtons records the buttons held and reports whether they changed.
+// The caller must hold o.mu.
+func (o *Overlay) updateButtons(b Buttons) bool {
+	if b == o.buttons {
+		return false
+	}
+	o.buttons = b
+	return true
+}
@@ -0,0 +1,108 @@
+package overlay
+
+import (
+	"log"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xfixes"
+)
+
+// initXFixes enables the XFixes extension, which reports cursor changes.
+// It reports whether the extension is available.
+func initXFixes(conn *xgb.Conn) bool {
+	if err := xfixes.Init(conn); err != nil {
+		return false
+	}
+	// The version must be negotiated before any other XFixes request.
+	_, err := xfixes.QueryVersion(conn, 4, 0).Reply()
+	return err == nil
+}
+
+// updateCursorWatch starts watching the cursor when auto_hide is on, and
+// shows the crosshair again when it is off. The caller must hold o.mu.
+func (o *Overlay) updateCursorWatch() {
+	if !o.config.Cursor.AutoHide {
+		o.stopCursorTimer()
+		if err := o.setHidden(HideCursorShown, false); err != nil {
+			log.Printf("Warning: failed to update crosshair visibility: %v", err)
+		}
+		return
+	}
+
+	if !o.hasXFixes {
+		if !o.cursorWarned {
+			log.Printf("Warning: cursor auto-hide unavailable: the X server has no XFixes extension")
+			o.cursorWarned = true
+		}
+		return
+	}
+
+	if !o.cursorWatched {
+		err := xfixes.SelectCursorInputChecked(o.conn, o.screen.Root, xfixes.CursorNotifyMaskDisplayCursor).Check()
+		if err != nil {
+			log.Printf("Warning: cursor auto-hide unavailable: %v", err)
+			return
+		}
+		o.cursorWatched = true
+	}
+
+	o.updateCursorHidden()
+}
+
+// handleCursorNotify waits for the cursor to settle for the debounce time,
+// then hides or shows the crosshair to match it.
+func (o *Overlay) handleCursorNotify() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.config.Cursor.AutoHide {
+		return
+	}
+
+	o.stopCursorTimer()
+	debounce := time.Duration(o.config.Cursor.Debounce) * time.Millisecond
+	if debounce <= 0 {
+		o.updateCursorHidden()
+		return
+	}
+
+	o.cursorTimer = time.AfterFunc(debounce, func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if o.config.Cursor.AutoHide {
+			o.updateCursorHidden()
+		}
+	})
+}
+
+// stopCursorTimer cancels a pending debounced update. The caller must hold o.mu.
+func (o *Overlay) stopCursorTimer() {
+	if o.cursorTimer != nil {
+		o.cursorTimer.Stop()
+		o.cursorTimer = nil
+	}
+}
+
+// updateCursorHidden hides the crosshair while the cursor is visible.
+// The caller must hold o.mu.
+func (o *Overlay) updateCursorHidden() {
+	if err := o.setHidden(HideCursorShown, o.cursorVisible()); err != nil {
+		log.Printf("Warning: failed to update crosshair visibility: %v", err)
+	}
+}
+
+// cursorVisible reports whether the cursor on screen has any pixel that is
+// not fully transparent. Games hide the cursor by showing a blank one.
+func (o *Overlay) cursorVisible() bool {
+	reply, err := xfixes.GetCursorImage(o.conn).Reply()
+	if err != nil {
+		return false
+	}
+	for _, pixel := range reply.CursorImage {
+		if pixel>>24 != 0 {
+			return true
+		}
+	}
+	return false
+}
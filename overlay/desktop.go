@@ -0,0 +1,39 @@
+package overlay
+
+import (
+	"encoding/binary"
+	"log"
+	"slices"
+
+	"github.com/jezek/xgb/xproto"
+)
+
+// updateDesktop hides the crosshair while the current virtual desktop is
+// not one of the configured desktops. Window managers that do not publish
+// _NET_CURRENT_DESKTOP leave it shown. The caller must hold o.mu.
+func (o *Overlay) updateDesktop() {
+	desktops := o.config.Position.Desktops
+	hide := false
+	if len(desktops) > 0 {
+		if current, ok := o.currentDesktop(); ok {
+			hide = !slices.Contains(desktops, int(current))
+		} else if !o.desktopWarned {
+			log.Printf("Warning: the window manager does not publish _NET_CURRENT_DESKTOP, showing the crosshair on every desktop")
+			o.desktopWarned = true
+		}
+	}
+
+	if err := o.setHidden(HideOtherDesktop, hide); err != nil {
+		log.Printf("Warning: failed to update crosshair visibility: %v", err)
+	}
+}
+
+// currentDesktop reads _NET_CURRENT_DESKTOP from the root window.
+func (o *Overlay) currentDesktop() (uint32, bool) {
+	reply, err := xproto.GetProperty(o.conn, false, o.screen.Root, o.atoms["_NET_CURRENT_DESKTOP"],
+		xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil || reply.Format != 32 || len(reply.Value) < 4 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(reply.Value), true
+}
@@ -0,0 +1,310 @@
+package overlay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+
+	"gocrosshair/config"
+	"gocrosshair/procscan"
+)
+
+// atomNames lists the atoms the overlay looks up, interned once at startup.
+var atomNames = []string{
+	"_NET_ACTIVE_WINDOW",
+	"_NET_CLIENT_LIST",
+	"_NET_CURRENT_DESKTOP",
+	"_NET_WM_NAME",
+	"_NET_WM_PID",
+	"_NET_WM_STATE",
+	"_NET_WM_STATE_FULLSCREEN",
+	"_SCREENSAVER_STATUS",
+	"UTF8_STRING",
+}
+
+// FocusedWindow describes the window that has the input focus, as reported
+// by the window manager through _NET_ACTIVE_WINDOW.
+type FocusedWindow struct {
+	ID       xproto.Window
+	Class    string // WM_CLASS class name, such as "steam_app_730"
+	Instance string // WM_CLASS instance name
+	PID      uint32 // From _NET_WM_PID, or 0 if the window does not set it
+	Exe      string // Executable of PID, or "" if it cannot be read
+}
+
+// internAtoms interns every atom in atomNames.
+func internAtoms(conn *xgb.Conn) (map[string]xproto.Atom, error) {
+	cookies := make([]xproto.InternAtomCookie, len(atomNames))
+	for i, name := range atomNames {
+		cookies[i] = xproto.InternAtom(conn, false, uint16(len(name)), name)
+	}
+
+	atoms := make(map[string]xproto.Atom, len(atomNames))
+	for i, cookie := range cookies {
+		reply, err := cookie.Reply()
+		if err != nil {
+			return nil, fmt.Errorf("failed to intern atom %s: %w", atomNames[i], err)
+		}
+		atoms[atomNames[i]] = reply.Atom
+	}
+	return atoms, nil
+}
+
+// WatchFocus calls fn with the focused window now and each time the focus
+// moves to another window. fn runs on the goroutine that processes events,
+// without the overlay lock held, so it may call Apply or SetHidden.
+func (o *Overlay) WatchFocus(fn func(FocusedWindow)) error {
+	if err := o.watchRootProperties(); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.onFocus = fn
+	o.updateActiveWindow()
+	id := o.activeWindow
+	o.mu.Unlock()
+
+	fn(o.describeWindow(id))
+	return nil
+}
+
+// SetFocusedFullscreen reports whether the focused window is fullscreen and
+// the area it covers, in root window coordinates, for callers that follow
+// the focus themselves, such as through a Wayland compositor's IPC. From the
+// first call on, fullscreen-only mode uses it instead of the _NET_WM_STATE
+// of the focused X window, until ClearFocusedFullscreen is called.
+func (o *Overlay) SetFocusedFullscreen(fullscreen bool, area xproto.Rectangle) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.extFocus = true
+	o.extFullscreen = fullscreen
+	o.extArea = area
+	o.updateFullscreen()
+}
+
+// ClearFocusedFullscreen goes back to reading the fullscreen state of the
+// focused X window, once the focus is no longer reported otherwise.
+func (o *Overlay) ClearFocusedFullscreen() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.extFocus = false
+	o.updateFullscreen()
+}
+
+// watchRootProperties asks for PropertyNotify events on the root window, which
+// is where the window manager publishes the focused window and client list.
+// While screen lockers are watched it also asks for the root window's
+// children being mapped and unmapped.
+func (o *Overlay) watchRootProperties() error {
+	mask := uint32(xproto.EventMaskPropertyChange)
+	if o.lockWatched {
+		mask |= xproto.EventMaskSubstructureNotify
+	}
+	err := xproto.ChangeWindowAttributesChecked(o.conn, o.screen.Root,
+		xproto.CwEventMask, []uint32{mask}).Check()
+	if err != nil {
+		return fmt.Errorf("failed to watch root window properties: %w", err)
+	}
+	return nil
+}
+
+// handlePropertyNotify reacts to the focus moving to another window, to
+// state changes of the focused window, to windows being opened or closed,
+// and to switching virtual desktops.
+func (o *Overlay) handlePropertyNotify(ev xproto.PropertyNotifyEvent) {
+	o.mu.Lock()
+	focused := false
+	switch {
+	case ev.Window == o.screen.Root && ev.Atom == o.atoms["_NET_ACTIVE_WINDOW"]:
+		focused = o.updateActiveWindow()
+	case ev.Window == o.screen.Root && ev.Atom == o.atoms["_NET_CLIENT_LIST"]:
+		if o.config.Position.Anchor == config.AnchorWindow {
+			oldX, oldY := o.centerX, o.centerY
+			o.updateAnchor()
+			o.reposition(oldX, oldY)
+		}
+	case ev.Window == o.activeWindow && ev.Atom == o.atoms["_NET_WM_STATE"]:
+		o.updateFullscreen()
+	case ev.Window == o.screen.Root && ev.Atom == o.atoms["_NET_CURRENT_DESKTOP"]:
+		o.updateDesktop()
+	case ev.Window == o.screen.Root && ev.Atom == o.atoms["_SCREENSAVER_STATUS"]:
+		if o.lockWatched {
+			o.updateXScreenSaverStatus()
+			o.updateLocked()
+		}
+	}
+	fn, id := o.onFocus, o.activeWindow
+	o.mu.Unlock()
+
+	if focused && fn != nil {
+		fn(o.describeWindow(id))
+	}
+}
+
+// handleConfigureNotify follows the anchor window and rechecks fullscreen
+// coverage when the focused window is moved or resized.
+func (o *Overlay) handleConfigureNotify(ev xproto.ConfigureNotifyEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// The root window's children also report here while screen lockers
+	// are watched; the windows the overlay follows report on their own.
+	if ev.Window == 0 || ev.Event == o.screen.Root {
+		return
+	}
+	if ev.Window == o.anchorWindow || ev.Window == o.anchorFrame {
+		oldX, oldY := o.centerX, o.centerY
+		o.updateAnchorGeometry()
+		o.reposition(oldX, oldY)
+	}
+	if ev.Window == o.activeWindow {
+		o.updateFullscreen()
+	}
+}
+
+// updateActiveWindow reads _NET_ACTIVE_WINDOW and, if the focus has moved,
+// starts following the new window's state and geometry. It reports whether
+// the focused window changed. The caller must hold o.mu.
+func (o *Overlay) updateActiveWindow() bool {
+	reply, err := xproto.GetProperty(o.conn, false, o.screen.Root, o.atoms["_NET_ACTIVE_WINDOW"],
+		xproto.AtomWindow, 0, 1).Reply()
+	if err != nil {
+		return false
+	}
+
+	var id xproto.Window
+	if reply.Format == 32 && len(reply.Value) >= 4 {
+		id = xproto.Window(binary.LittleEndian.Uint32(reply.Value))
+	}
+
+	if id == o.activeWindow && o.focusKnown {
+		return false
+	}
+
+	o.activeWindow = id
+	o.focusKnown = true
+	o.updateWatches()
+	o.updateFullscreen()
+	return true
+}
+
+// updateFullscreen hides the crosshair in fullscreen-only mode unless the
+// focused window is fullscreen and covers the selected monitor.
+// The caller must hold o.mu.
+func (o *Overlay) updateFullscreen() {
+	hide := o.config.Position.Visibility == config.VisibilityFullscreenOnly && !o.fullscreenOnMonitor()
+	if err := o.setHidden(HideNotFullscreen, hide); err != nil {
+		log.Printf("Warning: failed to update crosshair visibility: %v", err)
+	}
+}
+
+// fullscreenOnMonitor reports whether the focused window has
+// _NET_WM_STATE_FULLSCREEN set and covers the selected monitor. Once the
+// focus is reported with SetFocusedFullscreen, the reported state and area
+// are checked instead.
+func (o *Overlay) fullscreenOnMonitor() bool {
+	if o.extFocus {
+		a := o.extArea
+		return o.extFullscreen && o.coversMonitor(int(a.X), int(a.Y), int(a.Width), int(a.Height))
+	}
+
+	id := o.activeWindow
+	if id == 0 {
+		return false
+	}
+
+	state, err := xproto.GetProperty(o.conn, false, id, o.atoms["_NET_WM_STATE"],
+		xproto.AtomAtom, 0, 64).Reply()
+	if err != nil || state.Format != 32 {
+		return false
+	}
+
+	fullscreen := false
+	for i := 0; i+4 <= len(state.Value); i += 4 {
+		if xproto.Atom(binary.LittleEndian.Uint32(state.Value[i:])) == o.atoms["_NET_WM_STATE_FULLSCREEN"] {
+			fullscreen = true
+			break
+		}
+	}
+	if !fullscreen {
+		return false
+	}
+
+	geom, err := xproto.GetGeometry(o.conn, xproto.Drawable(id)).Reply()
+	if err != nil {
+		return false
+	}
+	pos, err := xproto.TranslateCoordinates(o.conn, id, o.screen.Root, 0, 0).Reply()
+	if err != nil {
+		return false
+	}
+
+	return o.coversMonitor(int(pos.DstX), int(pos.DstY), int(geom.Width), int(geom.Height))
+}
+
+// coversMonitor reports whether an area in root window coordinates covers
+// the whole selected monitor.
+func (o *Overlay) coversMonitor(x, y, width, height int) bool {
+	m := o.monitor
+	return x <= int(m.X) && y <= int(m.Y) &&
+		x+width >= int(m.X)+int(m.Width) &&
+		y+height >= int(m.Y)+int(m.Height)
+}
+
+// describeWindow reads the class and process of a window. Missing
+// properties are left empty; id 0 means no window has the focus.
+func (o *Overlay) describeWindow(id xproto.Window) FocusedWindow {
+	w := FocusedWindow{ID: id}
+	if id == 0 {
+		return w
+	}
+
+	w.Instance, w.Class = o.windowClass(id)
+	if w.PID = o.WindowPID(id); w.PID != 0 {
+		w.Exe = ProcessExe(w.PID)
+	}
+
+	return w
+}
+
+// WindowPID returns the process of a window from its _NET_WM_PID, or 0 if
+// the window does not set it.
+func (o *Overlay) WindowPID(id xproto.Window) uint32 {
+	reply, err := xproto.GetProperty(o.conn, false, id, o.atoms["_NET_WM_PID"],
+		xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil || reply.Format != 32 || len(reply.Value) < 4 {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(reply.Value)
+}
+
+// windowClass reads the instance and class names from a window's WM_CLASS.
+// Both are empty when the window does not set it.
+func (o *Overlay) windowClass(id xproto.Window) (instance, class string) {
+	reply, err := xproto.GetProperty(o.conn, false, id, xproto.AtomWmClass,
+		xproto.AtomString, 0, 256).Reply()
+	if err != nil || reply.Format != 8 {
+		return "", ""
+	}
+
+	// WM_CLASS holds two NUL-terminated strings: instance, then class.
+	parts := bytes.Split(bytes.TrimRight(reply.Value, "\x00"), []byte{0})
+	instance = string(parts[0])
+	if len(parts) > 1 {
+		class = string(parts[1])
+	}
+	return instance, class
+}
+
+// ProcessExe returns the executable name of a local process, or "" if it
+// cannot be read. The command name is used when /proc/PID/exe is not readable.
+func ProcessExe(pid uint32) string {
+	p, _ := procscan.New("").Process(int(pid))
+	return p.Exe
+}
@@ -0,0 +1,54 @@
+package overlay
+
+import (
+	"time"
+
+	"github.com/jezek/xgb/xproto"
+)
+
+// startHitmarker flashes the hitmarker from now, if it is enabled.
+// The caller must hold o.mu.
+func (o *Overlay) startHitmarker(now time.Time) {
+	if !o.config.Hitmarker.Enabled {
+		return
+	}
+	o.hitSince = now
+	o.hitStep = 0
+	o.hitActive = true
+	o.redraw()
+}
+
+// updateHitmarker advances the hitmarker's fade, redrawing the crosshair at
+// each step and once more to remove it when the flash is over. It reports
+// whether the hitmarker is still showing. The caller must hold o.mu.
+func (o *Overlay) updateHitmarker(now time.Time) bool {
+	if !o.hitActive {
+		return false
+	}
+
+	h := o.config.Hitmarker
+	step := int(now.Sub(o.hitSince) * time.Duration(h.FadeSteps) / (time.Duration(h.Duration) * time.Millisecond))
+	if !h.Enabled || step >= h.FadeSteps {
+		o.hitActive = false
+		o.redraw()
+		return false
+	}
+
+	if step != o.hitStep {
+		o.hitStep = step
+		o.redraw()
+	}
+	return true
+}
+
+// hitmarkerRects returns the hitmarker in window coordinates, or nil while
+// it is not showing. Its arms shrink by one fade step at a time.
+func (o *Overlay) hitmarkerRects() []xproto.Rectangle {
+	if !o.hitActive {
+		return nil
+	}
+
+	h := o.config.Hitmarker
+	size := int16(h.Size * (h.FadeSteps - o.hitStep) / h.FadeSteps)
+	return GenerateHitmarker(o.centerX-o.originX, o.centerY-o.originY, size, int16(h.Thickness), int16(h.Gap))
+}
@@ -0,0 +1,260 @@
+package overlay
+
+import (
+	"encoding/binary"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/screensaver"
+	"github.com/jezek/xgb/xproto"
+)
+
+// idleRecheck is how often the idle time is read while the crosshair is
+// hidden for idleness, so it comes back soon after the next input.
+const idleRecheck = 250 * time.Millisecond
+
+// lockerNames are the WM_CLASS or window names of screen lockers, matched
+// without regard to case. Lockers map a window over the whole screen, which
+// would otherwise sit below the crosshair.
+var lockerNames = []string{
+	"i3lock",
+	"xsecurelock",
+	"xscreensaver",
+	"slock",
+	"xlock",
+	"xtrlock",
+	"light-locker",
+	"gnome-screensaver",
+	"mate-screensaver",
+	"cinnamon-screensaver",
+	"kscreenlocker_greet",
+}
+
+// initScreenSaver enables the MIT-SCREEN-SAVER extension, which reports the
+// idle time and the screensaver state. It reports whether the extension is
+// available.
+func initScreenSaver(conn *xgb.Conn) bool {
+	if err := screensaver.Init(conn); err != nil {
+		return false
+	}
+	_, err := screensaver.QueryVersion(conn, 1, 1).Reply()
+	return err == nil
+}
+
+// updateIdleWatch starts or stops hiding the crosshair on idle and on lock
+// to match the configuration. The caller must hold o.mu.
+func (o *Overlay) updateIdleWatch() {
+	o.updateLockWatch()
+
+	after := time.Duration(o.config.Idle.HideAfter) * time.Second
+	if after == o.idleAfter && (o.idleStop != nil || after == 0) {
+		return
+	}
+
+	if o.idleStop != nil {
+		close(o.idleStop)
+		o.idleStop = nil
+	}
+	o.idleAfter = after
+	if after == 0 {
+		if err := o.setHidden(HideIdle, false); err != nil {
+			log.Printf("Warning: failed to update crosshair visibility: %v", err)
+		}
+		return
+	}
+
+	if !o.hasSaver {
+		if !o.saverWarned {
+			log.Printf("Warning: idle auto-hide unavailable: the X server has no MIT-SCREEN-SAVER extension")
+			o.saverWarned = true
+		}
+		return
+	}
+
+	o.idleStop = make(chan struct{})
+	go o.pollIdle(o.idleStop, after)
+}
+
+// pollIdle hides the crosshair once no input has arrived for after, and
+// shows it again on the next input, until stop is closed. It sleeps until
+// the idle time could next reach after, so it costs nothing while the
+// computer is in use.
+func (o *Overlay) pollIdle(stop <-chan struct{}, after time.Duration) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+
+		wait := idleRecheck
+		info, err := screensaver.QueryInfo(o.conn, xproto.Drawable(o.screen.Root)).Reply()
+		if err == nil {
+			idle := time.Duration(info.MsSinceUserInput) * time.Millisecond
+			hide := idle >= after
+			if !hide {
+				wait = after - idle
+			}
+
+			o.mu.Lock()
+			select {
+			case <-stop:
+				// Stopped while querying; the crosshair was already shown.
+			default:
+				if err := o.setHidden(HideIdle, hide); err != nil {
+					log.Printf("Warning: failed to update crosshair visibility: %v", err)
+				}
+			}
+			o.mu.Unlock()
+		}
+
+		timer.Reset(wait)
+	}
+}
+
+// updateLockWatch starts following the screensaver and the screen lockers
+// when hide_on_lock is on, and shows the crosshair again when it is off.
+// The caller must hold o.mu.
+func (o *Overlay) updateLockWatch() {
+	if !o.config.Idle.HideOnLock {
+		if err := o.setHidden(HideLocked, false); err != nil {
+			log.Printf("Warning: failed to update crosshair visibility: %v", err)
+		}
+		return
+	}
+
+	if !o.lockWatched {
+		// Lockers are found as they map their windows, which is reported
+		// with SubstructureNotify on the root window.
+		o.lockWatched = true
+		if err := o.watchRootProperties(); err != nil {
+			log.Printf("Warning: lock screen detection unavailable: %v", err)
+			o.lockWatched = false
+			return
+		}
+		o.scanLockers()
+		o.updateXScreenSaverStatus()
+
+		if o.hasSaver {
+			err := screensaver.SelectInputChecked(o.conn, xproto.Drawable(o.screen.Root),
+				screensaver.EventNotifyMask).Check()
+			if err != nil {
+				log.Printf("Warning: failed to watch the screensaver: %v", err)
+			} else if info, err := screensaver.QueryInfo(o.conn, xproto.Drawable(o.screen.Root)).Reply(); err == nil {
+				o.saverActive = info.State == screensaver.StateOn
+			}
+		}
+	}
+
+	o.updateLocked()
+}
+
+// updateLocked hides the crosshair while the screensaver runs or a locker
+// window is mapped. The caller must hold o.mu.
+func (o *Overlay) updateLocked() {
+	locked := o.saverActive || o.xsaverActive || len(o.lockers) > 0
+	if err := o.setHidden(HideLocked, locked && o.config.Idle.HideOnLock); err != nil {
+		log.Printf("Warning: failed to update crosshair visibility: %v", err)
+	}
+}
+
+// scanLockers finds locker windows that were mapped before the overlay
+// started watching. The caller must hold o.mu.
+func (o *Overlay) scanLockers() {
+	o.lockers = make(map[xproto.Window]bool)
+	tree, err := xproto.QueryTree(o.conn, o.screen.Root).Reply()
+	if err != nil {
+		return
+	}
+
+	// Send every request before waiting, so they share a round trip.
+	cookies := make([]xproto.GetWindowAttributesCookie, len(tree.Children))
+	for i, id := range tree.Children {
+		cookies[i] = xproto.GetWindowAttributes(o.conn, id)
+	}
+
+	for i, cookie := range cookies {
+		attrs, err := cookie.Reply()
+		if err != nil || attrs.MapState != xproto.MapStateViewable {
+			continue
+		}
+		if id := tree.Children[i]; o.isLocker(id) {
+			o.lockers[id] = true
+		}
+	}
+}
+
+// isLocker reports whether a window belongs to a known screen locker, by
+// its WM_CLASS or, for lockers that do not set one, its name.
+func (o *Overlay) isLocker(id xproto.Window) bool {
+	instance, class := o.windowClass(id)
+	if instance == "" && class == "" {
+		return isLockerName(o.windowTitle(id))
+	}
+	return isLockerName(instance) || isLockerName(class)
+}
+
+// isLockerName reports whether name is one of lockerNames.
+func isLockerName(name string) bool {
+	for _, locker := range lockerNames {
+		if strings.EqualFold(name, locker) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleMapNotify notices a screen locker mapping its window.
+func (o *Overlay) handleMapNotify(ev xproto.MapNotifyEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.lockWatched || ev.Event != o.screen.Root || ev.Window == o.windowID {
+		return
+	}
+	if o.isLocker(ev.Window) {
+		o.lockers[ev.Window] = true
+		o.updateLocked()
+	}
+}
+
+// forgetLocker notices a screen locker's window going away, through an
+// UnmapNotify or DestroyNotify event.
+func (o *Overlay) forgetLocker(id xproto.Window) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.lockers[id] {
+		delete(o.lockers, id)
+		o.updateLocked()
+	}
+}
+
+// handleScreenSaverNotify follows the screensaver starting and stopping.
+func (o *Overlay) handleScreenSaverNotify(ev screensaver.NotifyEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// StateCycle is reported while a running screensaver changes its image.
+	o.saverActive = ev.State == screensaver.StateOn || ev.State == screensaver.StateCycle
+	o.updateLocked()
+}
+
+// updateXScreenSaverStatus reads _SCREENSAVER_STATUS, which XScreenSaver
+// publishes on the root window. Its first value is the atom BLANK or LOCK
+// while the screen is blanked or locked, and 0 otherwise. XScreenSaver
+// draws into its own windows rather than the server's screensaver, so the
+// extension does not see it. The caller must hold o.mu.
+func (o *Overlay) updateXScreenSaverStatus() {
+	o.xsaverActive = false
+	reply, err := xproto.GetProperty(o.conn, false, o.screen.Root, o.atoms["_SCREENSAVER_STATUS"],
+		xproto.AtomInteger, 0, 1).Reply()
+	if err == nil && reply.Format == 32 && len(reply.Value) >= 4 {
+		o.xsaverActive = binary.LittleEndian.Uint32(reply.Value) != 0
+	}
+}
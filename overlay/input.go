@@ -0,0 +1,279 @@
+package overlay
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/jezek/xgb/record"
+	"github.com/jezek/xgb/xproto"
+)
+
+// frameInterval is how often the crosshair is redrawn while a spread or the
+// hitmarker animates. Nothing runs on a timer otherwise.
+const frameInterval = 8 * time.Millisecond
+
+// inputSource is a running RECORD context and the connection its events
+// arrive on.
+type inputSource struct {
+	ctx    record.Context
+	conn   net.Conn
+	motion bool // Pointer motion is recorded too
+
+	// last is the final event of the previous batch, which the first event
+	// of the next one may pair with as a key repeat. Guarded by Overlay.mu.
+	last inputEvent
+}
+
+// needsInput reports whether keyboard and mouse events have to be followed:
+// to follow the pointer, or to react to the mouse buttons or to keys.
+func (o *Overlay) needsInput() bool {
+	return o.followsPointer() || o.onButtons != nil || o.config.Mouse.Spread > 0 ||
+		o.config.Hitmarker.Enabled || len(o.moveKeys) > 0 || len(o.watchedKeys) > 0
+}
+
+// updateInput starts following keyboard and mouse events when they are
+// needed, and stops when they no longer are. Pointer motion, by far the
+// busiest event, is only recorded in pointer anchor mode. The caller must
+// hold o.mu.
+func (o *Overlay) updateInput() {
+	need := o.started && o.needsInput()
+	if o.input != nil && (!need || o.input.motion != o.followsPointer()) {
+		o.stopInput()
+	}
+	if !need || o.input != nil {
+		return
+	}
+
+	if err := o.startInput(); err != nil && !o.inputWarned {
+		log.Printf("Warning: mouse and keyboard reactions unavailable: %v", err)
+		o.inputWarned = true
+	}
+}
+
+// startInput creates a RECORD context for the device events of every
+// client and starts reading them. The caller must hold o.mu.
+func (o *Overlay) startInput() error {
+	if !o.hasRecord {
+		return errors.New("the X server has no RECORD extension")
+	}
+
+	ctx, err := record.NewContextId(o.conn)
+	if err != nil {
+		return err
+	}
+
+	last := byte(xproto.ButtonRelease)
+	if o.followsPointer() {
+		last = xproto.MotionNotify
+	}
+	ranges := []record.Range{{DeviceEvents: record.Range8{First: xproto.KeyPress, Last: last}}}
+	clients := []record.ClientSpec{record.CsAllClients}
+	if err := record.CreateContextChecked(o.conn, ctx, 0, 1, 1, clients, ranges).Check(); err != nil {
+		return err
+	}
+
+	o.conn.ExtLock.RLock()
+	opcode := o.conn.Extensions["RECORD"]
+	o.conn.ExtLock.RUnlock()
+
+	conn, err := openRecordStream(opcode, ctx)
+	if err != nil {
+		record.FreeContext(o.conn, ctx)
+		return err
+	}
+
+	src := &inputSource{ctx: ctx, conn: conn, motion: last == xproto.MotionNotify}
+	o.input = src
+	o.seedInput()
+	go o.readInput(src)
+	return nil
+}
+
+// stopInput disables and frees the RECORD context. The caller must hold o.mu.
+func (o *Overlay) stopInput() {
+	src := o.input
+	o.input = nil
+	record.DisableContext(o.conn, src.ctx)
+	record.FreeContext(o.conn, src.ctx)
+	src.conn.Close()
+	o.keysHeld = nil
+}
+
+// seedInput reads the state the event stream only reports changes of: where
+// the pointer is, which buttons are held and which keys are down.
+// The caller must hold o.mu.
+func (o *Overlay) seedInput() {
+	pointerCookie := xproto.QueryPointer(o.conn, o.screen.Root)
+	keymapCookie := xproto.QueryKeymap(o.conn)
+
+	o.keysHeld = make(map[xproto.Keycode]bool)
+	if keymap, err := keymapCookie.Reply(); err == nil {
+		for code := range 256 {
+			if keyDown(keymap.Keys, xproto.Keycode(code)) {
+				o.keysHeld[xproto.Keycode(code)] = true
+			}
+		}
+	}
+
+	if reply, err := pointerCookie.Reply(); err == nil {
+		o.buttons = buttonsFromMask(reply.Mask)
+		o.movePointer(reply.RootX, reply.RootY)
+	}
+}
+
+// readInput handles the events of src until it is stopped or fails.
+func (o *Overlay) readInput(src *inputSource) {
+	err := readRecordStream(src.conn, func(events []inputEvent) {
+		o.handleInput(src, events)
+	})
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.input == src {
+		log.Printf("Warning: stopped receiving mouse and keyboard events: %v", err)
+		o.input = nil
+		src.conn.Close()
+	}
+}
+
+// handleInput reacts to a batch of keyboard and mouse events: it moves the
+// crosshair with the pointer, spreads it and flashes the hitmarker, and
+// reports button changes and watched key presses. Every press is seen, no
+// matter how short.
+func (o *Overlay) handleInput(src *inputSource, events []inputEvent) {
+	o.mu.Lock()
+	if o.input != src {
+		o.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	var changes []Buttons
+	var pressed []uint32
+	moved := false
+	var x, y int16
+
+	for i, ev := range events {
+		switch ev.Type {
+		case xproto.MotionNotify:
+			moved, x, y = true, ev.RootX, ev.RootY
+
+		case xproto.ButtonPress, xproto.ButtonRelease:
+			down := ev.Type == xproto.ButtonPress
+			b := o.buttons
+			switch ev.Detail {
+			case xproto.ButtonIndex1:
+				if down && !b.Fire {
+					o.startHitmarker(now)
+				}
+				b.Fire = down
+			case xproto.ButtonIndex3:
+				b.Aim = down
+			}
+			if o.updateButtons(b) {
+				changes = append(changes, b)
+			}
+
+		case xproto.KeyPress, xproto.KeyRelease:
+			code := xproto.Keycode(ev.Detail)
+			if isAutoRepeat(src.last, events, i) {
+				// When the pair was split across two batches, the release
+				// has already been applied, so the key is held again here.
+				if ev.Type == xproto.KeyPress {
+					o.keysHeld[code] = true
+				}
+				continue
+			}
+			if ev.Type == xproto.KeyRelease {
+				delete(o.keysHeld, code)
+				break
+			}
+			if sym, ok := o.watchedKeys[code]; ok && !o.keysHeld[code] {
+				pressed = append(pressed, sym)
+			}
+			o.keysHeld[code] = true
+		}
+
+		o.updateSpread(o.buttons.Fire, o.movementHeld(), now)
+	}
+
+	if len(events) > 0 {
+		src.last = events[len(events)-1]
+	}
+	if moved {
+		o.movePointer(x, y)
+	}
+	o.updateAnimation(now)
+	onButtons, onKeyPress := o.onButtons, o.onKeyPress
+	o.mu.Unlock()
+
+	if onButtons != nil {
+		for _, b := range changes {
+			onButtons(b)
+		}
+	}
+	if onKeyPress != nil {
+		for _, sym := range pressed {
+			onKeyPress(sym)
+		}
+	}
+}
+
+// isAutoRepeat reports whether events[i] is half of the release and press
+// pair X sends, with one timestamp, for each repeat of a held key. prev is
+// the last event of the previous batch: a pair may be split between two
+// batches, in which case only the press is recognized.
+func isAutoRepeat(prev inputEvent, events []inputEvent, i int) bool {
+	ev := events[i]
+	var pair inputEvent
+	switch {
+	case ev.Type == xproto.KeyRelease && i+1 < len(events):
+		pair = events[i+1]
+	case ev.Type == xproto.KeyPress && i > 0:
+		pair = events[i-1]
+	case ev.Type == xproto.KeyPress:
+		pair = prev
+	default:
+		return false
+	}
+	return pair.Type != ev.Type && (pair.Type == xproto.KeyPress || pair.Type == xproto.KeyRelease) &&
+		pair.Detail == ev.Detail && pair.Time == ev.Time
+}
+
+// updateAnimation advances the spreads and the hitmarker, and keeps
+// redrawing on a timer while either still moves. The caller must hold o.mu.
+func (o *Overlay) updateAnimation(now time.Time) {
+	animating := o.updateSpread(o.buttons.Fire, o.movementHeld(), now)
+	animating = o.updateHitmarker(now) || animating
+	if animating && o.animStop == nil {
+		o.animStop = make(chan struct{})
+		go o.animate(o.animStop)
+	}
+}
+
+// animate redraws the crosshair every frame until nothing animates anymore.
+func (o *Overlay) animate(stop chan struct{}) {
+	ticker := time.NewTicker(frameInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		o.mu.Lock()
+		if o.animStop != stop {
+			o.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		animating := o.updateSpread(o.buttons.Fire, o.movementHeld(), now)
+		animating = o.updateHitmarker(now) || animating
+		if !animating {
+			o.animStop = nil
+		}
+		o.mu.Unlock()
+
+		if !animating {
+			return
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package overlay
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+
+	"gocrosshair/config"
+)
+
+// numLockKeysym is the keysym of the NumLock key, looked up to find which
+// modifier bit NumLock sets.
+const numLockKeysym = 0xff7f
+
+// KeyBinding binds a key chord to an action.
+type KeyBinding struct {
+	Action string
+	Chord  config.Chord
+}
+
+// KeyEvent is a press or release of a grabbed hotkey.
+type KeyEvent struct {
+	Action  string
+	Pressed bool // false when the key is released
+	Repeat  bool // a press generated by key repeat while the key is held
+}
+
+// keyGrab identifies a grabbed key: a keycode with the modifiers of its chord.
+type keyGrab struct {
+	keycode   xproto.Keycode
+	modifiers uint16
+}
+
+// GrabKeys grabs every binding's chord on the root window, so it reaches the
+// overlay whichever window has the focus, and calls fn when one is pressed or
+// released. Each chord is also grabbed with CapsLock and NumLock on. Chords
+// another program has already grabbed are skipped with a warning. It returns
+// the actions that were bound to at least one key. fn runs on the goroutine
+// that processes events, without the overlay lock held.
+func (o *Overlay) GrabKeys(bindings []KeyBinding, fn func(KeyEvent)) (map[string]bool, error) {
+	mapping, first, err := o.keyboardMapping()
+	if err != nil {
+		return nil, err
+	}
+
+	numLock := o.numLockMask(mapping, first)
+	locks := []uint16{0, xproto.ModMaskLock, numLock, xproto.ModMaskLock | numLock}
+
+	grabs := make(map[keyGrab]string)
+	bound := make(map[string]bool)
+	for _, b := range bindings {
+		codes := keycodesFor(mapping, first, b.Chord.Keysym)
+		if len(codes) == 0 {
+			log.Printf("Warning: no key on this keyboard produces the %s hotkey", b.Action)
+			continue
+		}
+
+		for _, code := range codes {
+			var err error
+			var grabbed []uint16
+			for _, lock := range locks {
+				err = xproto.GrabKeyChecked(o.conn, true, o.screen.Root, b.Chord.Modifiers|lock, code,
+					xproto.GrabModeAsync, xproto.GrabModeAsync).Check()
+				if err != nil {
+					break
+				}
+				grabbed = append(grabbed, lock)
+			}
+			if err != nil {
+				// Release the lock variants that did succeed, so the key is
+				// not half taken from the program that owns it.
+				for _, lock := range grabbed {
+					xproto.UngrabKey(o.conn, code, o.screen.Root, b.Chord.Modifiers|lock)
+				}
+				log.Printf("Warning: the %s hotkey is already used by another program", b.Action)
+				continue
+			}
+			grabs[keyGrab{code, b.Chord.Modifiers}] = b.Action
+			bound[b.Action] = true
+		}
+	}
+
+	o.mu.Lock()
+	o.keyGrabs = grabs
+	o.lockMods = xproto.ModMaskLock | numLock
+	o.keysDown = make(map[xproto.Keycode]string)
+	o.onKey = fn
+	o.mu.Unlock()
+
+	return bound, nil
+}
+
+// WatchKeyPresses calls fn with the keysym whenever one of syms is pressed.
+// The keys are followed through the RECORD extension rather than grabbed, so
+// they still reach the focused window. Key repeat is ignored. fn runs on the
+// goroutine reading the events, without the overlay lock held, so it may
+// call Apply.
+func (o *Overlay) WatchKeyPresses(syms []uint32, fn func(uint32)) error {
+	mapping, first, err := o.keyboardMapping()
+	if err != nil {
+		return err
+	}
+
+	codes := make(map[xproto.Keycode]uint32)
+	for _, sym := range syms {
+		for _, code := range keycodesFor(mapping, first, sym) {
+			codes[code] = sym
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.watchedKeys = codes
+	o.onKeyPress = fn
+	o.updateInput()
+	return nil
+}
+
+// keyDown reports whether code is down in keymap, the bitmap of pressed
+// keys QueryKeymap reports.
+func keyDown(keymap []byte, code xproto.Keycode) bool {
+	return int(code/8) < len(keymap) && keymap[code/8]&(1<<(code%8)) != 0
+}
+
+// keyboardMapping reads the keysyms of every keycode. It returns them with
+// the first keycode they start at.
+func (o *Overlay) keyboardMapping() (*xproto.GetKeyboardMappingReply, xproto.Keycode, error) {
+	setup := xproto.Setup(o.conn)
+	first := setup.MinKeycode
+	mapping, err := xproto.GetKeyboardMapping(o.conn, first, byte(setup.MaxKeycode-first+1)).Reply()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read keyboard mapping: %w", err)
+	}
+	return mapping, first, nil
+}
+
+// keycodesFor returns the keycodes that produce sym without a level shift
+// other than Shift.
+func keycodesFor(mapping *xproto.GetKeyboardMappingReply, first xproto.Keycode, sym uint32) []xproto.Keycode {
+	per := int(mapping.KeysymsPerKeycode)
+	if per == 0 {
+		return nil
+	}
+
+	var codes []xproto.Keycode
+	for i := 0; (i+1)*per <= len(mapping.Keysyms); i++ {
+		for level := 0; level < min(per, 2); level++ {
+			if uint32(mapping.Keysyms[i*per+level]) == sym {
+				codes = append(codes, first+xproto.Keycode(i))
+				break
+			}
+		}
+	}
+	return codes
+}
+
+// numLockMask returns the modifier bit NumLock sets, which is Mod2 on most
+// keyboards.
+func (o *Overlay) numLockMask(mapping *xproto.GetKeyboardMappingReply, first xproto.Keycode) uint16 {
+	mods, err := xproto.GetModifierMapping(o.conn).Reply()
+	if err != nil || mods.KeycodesPerModifier == 0 {
+		return xproto.ModMask2
+	}
+
+	numLockCodes := keycodesFor(mapping, first, numLockKeysym)
+	for i, code := range mods.Keycodes {
+		for _, numLock := range numLockCodes {
+			if code != 0 && code == numLock {
+				return 1 << (i / int(mods.KeycodesPerModifier))
+			}
+		}
+	}
+	return xproto.ModMask2
+}
+
+// handleKey reports a press or release of a grabbed key to the callback.
+// Releases are matched to the press by keycode alone, since the modifiers
+// may have been let go first.
+func (o *Overlay) handleKey(code xproto.Keycode, state uint16, pressed, repeat bool) {
+	o.mu.Lock()
+	var action string
+	if pressed {
+		mods := state & 0xff &^ o.lockMods
+		action = o.keyGrabs[keyGrab{code, mods}]
+		if action != "" {
+			o.keysDown[code] = action
+		}
+	} else {
+		action = o.keysDown[code]
+		delete(o.keysDown, code)
+	}
+	fn := o.onKey
+	o.mu.Unlock()
+
+	if action != "" && fn != nil {
+		fn(KeyEvent{Action: action, Pressed: pressed, Repeat: repeat})
+	}
+}
+
+// handleKeyRelease reports a key release, unless it is half of the release
+// and press pair that key repeat generates with the same timestamp. In that
+// case the press is reported as a repeat instead. It returns the event that
+// was read ahead and has not been handled, if any.
+func (o *Overlay) handleKeyRelease(ev xproto.KeyReleaseEvent) (xgb.Event, xgb.Error) {
+	next, err := o.conn.PollForEvent()
+	if press, ok := next.(xproto.KeyPressEvent); ok && press.Detail == ev.Detail && press.Time == ev.Time {
+		o.handleKey(press.Detail, press.State, true, true)
+		return nil, nil
+	}
+
+	o.handleKey(ev.Detail, ev.State, false, false)
+	return next, err
+}
@@ -3,9 +3,13 @@ package overlay
 import (
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/screensaver"
 	"github.com/jezek/xgb/shape"
+	"github.com/jezek/xgb/xfixes"
 	"github.com/jezek/xgb/xproto"
 
 	"gocrosshair/config"
@@ -18,12 +22,100 @@ type Overlay struct {
 	windowID  xproto.Window
 	gcID      xproto.Gcontext
 	outlineGC xproto.Gcontext
+	hitGC     xproto.Gcontext
 	config    *config.Config
+	monitors  []Monitor
 	monitor   Monitor
 	centerX   int16
 	centerY   int16
+	originX   int16 // Window position, subtracted from the center when drawing
+	originY   int16
+	started   bool
+	mapped    bool
+	hidden    HideReason
+
+	atoms         map[string]xproto.Atom
+	onFocus       func(FocusedWindow)
+	activeWindow  xproto.Window
+	focusKnown    bool
+	extFocus      bool // Focus is reported with SetFocusedFullscreen
+	extFullscreen bool
+	extArea       xproto.Rectangle // Area the focused window covers, with extFocus
+	anchorWindow  xproto.Window
+	anchorFrame   xproto.Window
+	anchorRect    xproto.Rectangle
+	watched       map[xproto.Window]bool
+	pointerX      int16
+	pointerY      int16
+	pointerKnown  bool
+	hasRecord     bool
+	input         *inputSource // Running RECORD context, or nil
+	inputWarned   bool
+	animStop      chan struct{} // Open while a spread or the hitmarker animates
+	onButtons     func(Buttons)
+	buttons       Buttons
+	fire          spread // Opens while the left button is held
+	move          spread // Opens while a movement key is held
+	fireGap       int16  // Pixels the gap is currently widened by firing
+	moveGap       int16  // Pixels the gap and arms currently grow by moving
+	moveKeys      []xproto.Keycode
+	watchedKeys   map[xproto.Keycode]uint32
+	onKeyPress    func(uint32)
+	keysHeld      map[xproto.Keycode]bool
+	hitActive     bool      // Hitmarker showing
+	hitSince      time.Time // When the hitmarker was last flashed
+	hitStep       int       // Fade step the hitmarker is drawn at
+	hasXFixes     bool
+	cursorWatched bool
+	cursorWarned  bool
+	cursorTimer   *time.Timer // Debounces cursor changes
+	hasSaver      bool
+	saverWarned   bool
+	idleStop      chan struct{}
+	idleAfter     time.Duration // Idle time the running pollIdle hides after
+	lockWatched   bool
+	lockers       map[xproto.Window]bool // Mapped screen locker windows
+	saverActive   bool                   // Screensaver running, from MIT-SCREEN-SAVER
+	xsaverActive  bool                   // XScreenSaver blanked or locked the screen
+	desktopWarned bool
+	onKey         func(KeyEvent)
+	keyGrabs      map[keyGrab]string
+	keysDown      map[xproto.Keycode]string
+	lockMods      uint16
+
+	// mu guards the fields above once the overlay is running, since Apply
+	// may be called from another goroutine than the event loop.
+	mu sync.Mutex
 }
 
+// HideReason is a cause for hiding the crosshair. The overlay is shown only
+// while no reason applies.
+type HideReason uint
+
+const (
+	// HideUnmatched hides the crosshair when no profile matches the focused window.
+	HideUnmatched HideReason = 1 << iota
+	// HideNotFullscreen hides the crosshair in fullscreen-only mode while no
+	// fullscreen window is focused on the selected monitor.
+	HideNotFullscreen
+	// HideToggled hides the crosshair after it was switched off with a hotkey.
+	HideToggled
+	// HideReleased hides the crosshair while the hold-to-show hotkey is up.
+	HideReleased
+	// HideAiming hides the crosshair while the right mouse button is held.
+	HideAiming
+	// HideCursorShown hides the crosshair while the mouse cursor is visible.
+	HideCursorShown
+	// HideIdle hides the crosshair after a period without keyboard or mouse input.
+	HideIdle
+	// HideLocked hides the crosshair while the screen is locked or the
+	// screensaver runs.
+	HideLocked
+	// HideOtherDesktop hides the crosshair on virtual desktops other than
+	// the configured ones.
+	HideOtherDesktop
+)
+
 // NewOverlay creates a new crosshair overlay connected to the X server.
 func NewOverlay(cfg *config.Config) (*Overlay, error) {
 	conn, err := xgb.NewConn()
@@ -53,23 +145,122 @@ func NewOverlay(cfg *config.Config) (*Overlay, error) {
 		}}
 	}
 
-	monitor := SelectMonitor(monitors, cfg.Position.Monitor)
+	atoms, err := internAtoms(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	o := &Overlay{
+		conn:      conn,
+		screen:    screen,
+		monitors:  monitors,
+		atoms:     atoms,
+		hasXFixes: initXFixes(conn),
+		hasSaver:  initScreenSaver(conn),
+		hasRecord: initRecord(conn),
+	}
+	o.setConfig(cfg)
+
+	return o, nil
+}
+
+// setConfig stores cfg and recomputes the monitor, anchor window and
+// crosshair center.
+func (o *Overlay) setConfig(cfg *config.Config) {
+	o.config = cfg
+	o.monitor = SelectMonitor(o.monitors, cfg.Position.Monitor)
+	o.updateAnchor()
+}
+
+// Apply switches a running overlay to a new configuration and redraws it.
+// Before Start it only records the configuration.
+func (o *Overlay) Apply(cfg *config.Config) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.setConfig(cfg)
+	if !o.started {
+		return nil
+	}
+
+	// The anchor may have switched to or from the pointer.
+	if err := o.layoutWindow(); err != nil {
+		return err
+	}
+	o.updateMovementKeys()
+	o.updateInput()
+
+	if err := o.updateGraphicsContext(); err != nil {
+		return err
+	}
+
+	if err := o.applyShape(); err != nil {
+		return err
+	}
+
+	// The visibility mode, desktops or monitor may have changed.
+	o.updateFullscreen()
+	o.updateDesktop()
+	o.updateCursorWatch()
+	o.updateIdleWatch()
+
+	return o.drawCrosshair()
+}
 
-	centerX := monitor.CenterX() + int16(cfg.Position.OffsetX)
-	centerY := monitor.CenterY() + int16(cfg.Position.OffsetY)
+// SetHidden adds or removes a reason for hiding the crosshair and maps or
+// unmaps the window to match.
+func (o *Overlay) SetHidden(reason HideReason, hidden bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 
-	return &Overlay{
-		conn:    conn,
-		screen:  screen,
-		config:  cfg,
-		monitor: monitor,
-		centerX: centerX,
-		centerY: centerY,
-	}, nil
+	return o.setHidden(reason, hidden)
+}
+
+// setHidden is SetHidden for callers that hold o.mu.
+func (o *Overlay) setHidden(reason HideReason, hidden bool) error {
+	if hidden {
+		o.hidden |= reason
+	} else {
+		o.hidden &^= reason
+	}
+
+	return o.updateMapping()
+}
+
+// updateMapping maps the window when nothing hides it and unmaps it otherwise.
+// The crosshair is redrawn on the Expose event that follows mapping.
+func (o *Overlay) updateMapping() error {
+	if !o.started {
+		return nil
+	}
+
+	visible := o.hidden == 0
+	if visible == o.mapped {
+		return nil
+	}
+
+	if visible {
+		if err := xproto.MapWindowChecked(o.conn, o.windowID).Check(); err != nil {
+			return fmt.Errorf("failed to map window: %w", err)
+		}
+	} else {
+		if err := xproto.UnmapWindowChecked(o.conn, o.windowID).Check(); err != nil {
+			return fmt.Errorf("failed to unmap window: %w", err)
+		}
+	}
+
+	o.mapped = visible
+	return nil
 }
 
 // Close releases X server resources and closes the connection.
 func (o *Overlay) Close() {
+	o.mu.Lock()
+	if o.input != nil {
+		o.input.conn.Close()
+	}
+	o.mu.Unlock()
 	if o.conn != nil {
 		o.conn.Close()
 	}
@@ -83,9 +274,10 @@ func (o *Overlay) createWindow() error {
 	}
 	o.windowID = wid
 
-	// Create a full-screen window to ensure we can draw anywhere
-	screenWidth := o.screen.WidthInPixels
-	screenHeight := o.screen.HeightInPixels
+	// Create a full-screen window to ensure we can draw anywhere, or a small
+	// one that is moved with the pointer in pointer anchor mode
+	x, y, width, height := o.windowGeometry()
+	o.originX, o.originY = x, y
 
 	// Window attributes:
 	// - OverrideRedirect: bypass window manager (no decorations, absolute positioning)
@@ -103,9 +295,9 @@ func (o *Overlay) createWindow() error {
 		o.screen.RootDepth,
 		o.windowID,
 		o.screen.Root,
-		0, 0,
-		screenWidth,
-		screenHeight,
+		x, y,
+		width,
+		height,
 		0,
 		xproto.WindowClassInputOutput,
 		o.screen.RootVisual,
@@ -136,6 +328,16 @@ func (o *Overlay) createGraphicsContext() error {
 		return fmt.Errorf("failed to create GC: %w", err)
 	}
 
+	hitGC, err := xproto.NewGcontextId(o.conn)
+	if err != nil {
+		return fmt.Errorf("failed to create hitmarker GC ID: %w", err)
+	}
+	o.hitGC = hitGC
+
+	if err := xproto.CreateGCChecked(o.conn, o.hitGC, xproto.Drawable(o.windowID), mask, []uint32{o.config.GetHitmarkerColorUint32()}).Check(); err != nil {
+		return fmt.Errorf("failed to create hitmarker GC: %w", err)
+	}
+
 	if o.config.Crosshair.OutlineThickness > 0 {
 		outlineGC, err := xproto.NewGcontextId(o.conn)
 		if err != nil {
@@ -152,17 +354,56 @@ func (o *Overlay) createGraphicsContext() error {
 	return nil
 }
 
+// updateGraphicsContext sets the GC colors from the current configuration,
+// creating the outline GC the first time an outline is enabled.
+func (o *Overlay) updateGraphicsContext() error {
+	mask := uint32(xproto.GcForeground)
+
+	if err := xproto.ChangeGCChecked(o.conn, o.gcID, mask, []uint32{o.config.GetColorUint32()}).Check(); err != nil {
+		return fmt.Errorf("failed to update GC: %w", err)
+	}
+
+	if err := xproto.ChangeGCChecked(o.conn, o.hitGC, mask, []uint32{o.config.GetHitmarkerColorUint32()}).Check(); err != nil {
+		return fmt.Errorf("failed to update hitmarker GC: %w", err)
+	}
+
+	if o.config.Crosshair.OutlineThickness <= 0 {
+		return nil
+	}
+
+	outlineColor := o.config.GetOutlineColorUint32()
+	if o.outlineGC != 0 {
+		if err := xproto.ChangeGCChecked(o.conn, o.outlineGC, mask, []uint32{outlineColor}).Check(); err != nil {
+			return fmt.Errorf("failed to update outline GC: %w", err)
+		}
+		return nil
+	}
+
+	outlineGC, err := xproto.NewGcontextId(o.conn)
+	if err != nil {
+		return fmt.Errorf("failed to create outline GC ID: %w", err)
+	}
+	o.outlineGC = outlineGC
+
+	if err := xproto.CreateGCChecked(o.conn, o.outlineGC, xproto.Drawable(o.windowID), mask, []uint32{outlineColor}).Check(); err != nil {
+		return fmt.Errorf("failed to create outline GC: %w", err)
+	}
+
+	return nil
+}
+
 // applyShape configures the window shape for transparency and click-through.
 func (o *Overlay) applyShape() error {
 	cfg := o.config.Crosshair
+	size, gap := o.dimensions()
 
 	shapeRects := GenerateShape(
 		cfg.Shape,
-		o.centerX,
-		o.centerY,
-		int16(cfg.Size),
+		o.centerX-o.originX,
+		o.centerY-o.originY,
+		size,
 		int16(cfg.Thickness),
-		int16(cfg.Gap),
+		gap,
 	)
 
 	var boundingRects []xproto.Rectangle
@@ -171,6 +412,7 @@ func (o *Overlay) applyShape() error {
 		boundingRects = append(boundingRects, outlineRects...)
 	}
 	boundingRects = append(boundingRects, shapeRects...)
+	boundingRects = append(boundingRects, o.hitmarkerRects()...)
 
 	// Set the BOUNDING shape: defines the visible area of the window
 	err := shape.RectanglesChecked(
@@ -206,14 +448,15 @@ func (o *Overlay) applyShape() error {
 // drawCrosshair renders the crosshair onto the window.
 func (o *Overlay) drawCrosshair() error {
 	cfg := o.config.Crosshair
+	size, gap := o.dimensions()
 
 	shapeRects := GenerateShape(
 		cfg.Shape,
-		o.centerX,
-		o.centerY,
-		int16(cfg.Size),
+		o.centerX-o.originX,
+		o.centerY-o.originY,
+		size,
 		int16(cfg.Thickness),
-		int16(cfg.Gap),
+		gap,
 	)
 
 	if cfg.OutlineThickness > 0 && o.outlineGC != 0 {
@@ -231,11 +474,26 @@ func (o *Overlay) drawCrosshair() error {
 		}
 	}
 
+	// The hitmarker is drawn last, on top of the crosshair.
+	if hitRects := o.hitmarkerRects(); len(hitRects) > 0 {
+		if err := xproto.PolyFillRectangleChecked(o.conn, xproto.Drawable(o.windowID), o.hitGC, hitRects).Check(); err != nil {
+			return fmt.Errorf("failed to draw hitmarker: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// Run initializes and runs the overlay event loop.
-func (o *Overlay) Run() error {
+// Start creates, maps and draws the overlay window without entering the
+// event loop. Run calls it automatically if it has not been called yet.
+func (o *Overlay) Start() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.started {
+		return nil
+	}
+
 	if err := o.createWindow(); err != nil {
 		return err
 	}
@@ -248,19 +506,56 @@ func (o *Overlay) Run() error {
 		return err
 	}
 
-	if err := xproto.MapWindowChecked(o.conn, o.windowID).Check(); err != nil {
-		return fmt.Errorf("failed to map window: %w", err)
+	o.started = true
+
+	// Follow the focused window for fullscreen-only mode. Window managers
+	// that do not publish _NET_ACTIVE_WINDOW never report a fullscreen window.
+	if err := o.watchRootProperties(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	o.focusKnown = false
+	o.updateActiveWindow()
+	o.updateDesktop()
+	o.updateMovementKeys()
+	o.updateInput()
+	o.updateCursorWatch()
+	o.updateIdleWatch()
+
+	if err := o.updateMapping(); err != nil {
+		return err
 	}
 
-	if err := o.drawCrosshair(); err != nil {
+	return o.drawCrosshair()
+}
+
+// Run initializes and runs the overlay event loop.
+func (o *Overlay) Run() error {
+	if err := o.Start(); err != nil {
 		return err
 	}
 
+	o.mu.Lock()
 	log.Printf("Crosshair overlay running on monitor %q at (%d, %d). Press Ctrl+C to exit.",
 		o.monitor.Name, o.centerX, o.centerY)
+	o.mu.Unlock()
+
+	return o.ProcessEvents()
+}
+
+// ProcessEvents handles X events until the connection is closed. Run calls it
+// after Start; callers that started the overlay themselves can run it in a
+// goroutine to keep the window redrawn.
+func (o *Overlay) ProcessEvents() error {
+	// Telling key repeat apart from a release reads one event ahead.
+	var pending xgb.Event
+	var pendingErr xgb.Error
 
 	for {
-		ev, err := o.conn.WaitForEvent()
+		ev, err := pending, pendingErr
+		pending, pendingErr = nil, nil
+		if ev == nil && err == nil {
+			ev, err = o.conn.WaitForEvent()
+		}
 		if err != nil {
 			return fmt.Errorf("X11 connection error: %w", err)
 		}
@@ -269,15 +564,63 @@ func (o *Overlay) Run() error {
 			return nil
 		}
 
-		switch ev.(type) {
+		switch ev := ev.(type) {
 		case xproto.ExposeEvent:
-			if err := o.drawCrosshair(); err != nil {
+			o.mu.Lock()
+			err := o.drawCrosshair()
+			o.mu.Unlock()
+			if err != nil {
 				log.Printf("Warning: failed to redraw crosshair: %v", err)
 			}
+
+			// The crosshair is also redrawn on a timer while its gap spreads
+			// or the hitmarker fades (see updateAnimation).
+
+		case xproto.PropertyNotifyEvent:
+			o.handlePropertyNotify(ev)
+
+		case xproto.ConfigureNotifyEvent:
+			o.handleConfigureNotify(ev)
+
+		case xfixes.CursorNotifyEvent:
+			o.handleCursorNotify()
+
+		case screensaver.NotifyEvent:
+			o.handleScreenSaverNotify(ev)
+
+		case xproto.MapNotifyEvent:
+			o.handleMapNotify(ev)
+
+		case xproto.UnmapNotifyEvent:
+			o.forgetLocker(ev.Window)
+
+		case xproto.DestroyNotifyEvent:
+			o.forgetLocker(ev.Window)
+
+		case xproto.KeyPressEvent:
+			o.handleKey(ev.Detail, ev.State, true, false)
+
+		case xproto.KeyReleaseEvent:
+			pending, pendingErr = o.handleKeyRelease(ev)
 		}
 	}
 }
 
+// redraw reshapes and redraws the crosshair after its position or
+// dimensions changed. The caller must hold o.mu.
+func (o *Overlay) redraw() {
+	if !o.started {
+		return
+	}
+	if err := o.applyShape(); err != nil {
+		log.Printf("Warning: failed to reshape crosshair: %v", err)
+		return
+	}
+	if err := o.drawCrosshair(); err != nil {
+		log.Printf("Warning: failed to redraw crosshair: %v", err)
+	}
+}
+
 // ListMonitors connects to X server and prints available monitors.
 func ListMonitors() error {
 	conn, err := xgb.NewConn()
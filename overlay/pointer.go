@@ -0,0 +1,91 @@
+package overlay
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jezek/xgb/xproto"
+
+	"gocrosshair/config"
+)
+
+// followsPointer reports whether the crosshair is anchored to the pointer.
+func (o *Overlay) followsPointer() bool {
+	return o.config.Position.Anchor == config.AnchorPointer
+}
+
+// crosshairRadius returns the distance from the crosshair's center to the
+// edge of its bounding box, including the outline and the hitmarker.
+func (o *Overlay) crosshairRadius() int16 {
+	// Leave room for the widest spread, so the window keeps its size.
+	cfg := o.config.Crosshair
+	size, gap := o.maxDimensions()
+	rects := GenerateShape(cfg.Shape, 0, 0, size, int16(cfg.Thickness), gap)
+	if cfg.OutlineThickness > 0 {
+		rects = append(rects, GenerateOutline(rects, int16(cfg.OutlineThickness))...)
+	}
+
+	if h := o.config.Hitmarker; h.Enabled {
+		rects = append(rects, GenerateHitmarker(0, 0, int16(h.Size), int16(h.Thickness), int16(h.Gap))...)
+	}
+
+	var r int16
+	for _, rect := range rects {
+		r = max(r, -rect.X, -rect.Y, rect.X+int16(rect.Width), rect.Y+int16(rect.Height))
+	}
+	return r + 1
+}
+
+// windowGeometry returns where the overlay window belongs: in pointer mode a
+// small window just large enough for the crosshair, centered on it, and
+// otherwise a window covering the whole screen.
+func (o *Overlay) windowGeometry() (x, y int16, width, height uint16) {
+	if !o.followsPointer() {
+		return 0, 0, o.screen.WidthInPixels, o.screen.HeightInPixels
+	}
+	r := o.crosshairRadius()
+	return o.centerX - r, o.centerY - r, uint16(2*r + 1), uint16(2*r + 1)
+}
+
+// layoutWindow moves and resizes the overlay window to windowGeometry.
+// The caller must hold o.mu.
+func (o *Overlay) layoutWindow() error {
+	x, y, width, height := o.windowGeometry()
+	o.originX, o.originY = x, y
+	if !o.started {
+		return nil
+	}
+
+	mask := uint16(xproto.ConfigWindowX | xproto.ConfigWindowY | xproto.ConfigWindowWidth | xproto.ConfigWindowHeight)
+	values := []uint32{uint32(int32(x)), uint32(int32(y)), uint32(width), uint32(height)}
+	if err := xproto.ConfigureWindowChecked(o.conn, o.windowID, mask, values).Check(); err != nil {
+		return fmt.Errorf("failed to resize window: %w", err)
+	}
+	return nil
+}
+
+// movePointer records the pointer position and, in pointer anchor mode,
+// moves the crosshair window to it. The caller must hold o.mu.
+func (o *Overlay) movePointer(x, y int16) {
+	if o.pointerKnown && x == o.pointerX && y == o.pointerY {
+		return
+	}
+	o.pointerX, o.pointerY, o.pointerKnown = x, y, true
+
+	if !o.followsPointer() {
+		return
+	}
+
+	o.updateCenter()
+	o.originX, o.originY, _, _ = o.windowGeometry()
+	if !o.started {
+		return
+	}
+
+	// The shape is relative to the window, so only the window moves.
+	mask := uint16(xproto.ConfigWindowX | xproto.ConfigWindowY)
+	values := []uint32{uint32(int32(o.originX)), uint32(int32(o.originY))}
+	if err := xproto.ConfigureWindowChecked(o.conn, o.windowID, mask, values).Check(); err != nil {
+		log.Printf("Warning: failed to move crosshair: %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package overlay
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/record"
+)
+
+// RECORD reply categories the stream reader tells apart.
+const (
+	recordFromServer = 0 // Copies of events the server sent
+	recordEndOfData  = 5 // The context was disabled
+)
+
+// Xauthority address families, as in Xauth.h.
+const (
+	xauthFamilyInternet  = 0
+	xauthFamilyInternet6 = 6
+	xauthFamilyLocal     = 256
+	xauthFamilyWild      = 65535
+)
+
+// maxRecordReply bounds the data of one RECORD reply.
+const maxRecordReply = 1 << 20
+
+// inputEvent is a device event copied from the RECORD stream.
+type inputEvent struct {
+	Type   byte // xproto.KeyPress through xproto.MotionNotify
+	Detail byte // Keycode or button number
+	Time   uint32
+	RootX  int16
+	RootY  int16
+}
+
+// initRecord enables the RECORD extension, which copies the keyboard and
+// mouse events the server dispatches without grabbing them away from the
+// focused window. It reports whether the extension is available.
+func initRecord(conn *xgb.Conn) bool {
+	if err := record.Init(conn); err != nil {
+		return false
+	}
+	_, err := record.QueryVersion(conn, 1, 13).Reply()
+	return err == nil
+}
+
+// openRecordStream opens a second connection to the X server and enables
+// the RECORD context ctx on it. Once enabled, the server answers the single
+// EnableContext request with one reply per batch of recorded events until
+// the context is disabled. xgb matches exactly one reply to each request,
+// so this connection is spoken to directly rather than through xgb.
+func openRecordStream(opcode byte, ctx record.Context) (net.Conn, error) {
+	conn, host, display, err := dialDisplay()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setupConnection(conn, host, display); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// EnableContext: the extension's opcode, minor opcode 5, a length of
+	// two 4-byte units, and the context.
+	req := make([]byte, 8)
+	req[0] = opcode
+	req[1] = 5
+	binary.LittleEndian.PutUint16(req[2:], 2)
+	binary.LittleEndian.PutUint32(req[4:], uint32(ctx))
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable input recording: %w", err)
+	}
+
+	return conn, nil
+}
+
+// readRecordStream reads RECORD replies from r and calls fn with the device
+// events of each, until the context is disabled or r fails.
+func readRecordStream(r io.Reader, fn func([]inputEvent)) error {
+	header := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return err
+		}
+		if header[0] == 0 {
+			return fmt.Errorf("input recording failed with X error %d", header[1])
+		}
+
+		size := binary.LittleEndian.Uint32(header[4:]) * 4
+		if size > maxRecordReply {
+			return fmt.Errorf("input recording reply too large (%d bytes)", size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return err
+		}
+
+		switch header[1] {
+		case recordEndOfData:
+			return nil
+		case recordFromServer:
+			// Without element headers the data is a run of 32-byte events.
+			var events []inputEvent
+			for i := 0; i+32 <= len(data); i += 32 {
+				ev := data[i : i+32]
+				events = append(events, inputEvent{
+					Type:   ev[0] & 0x7f,
+					Detail: ev[1],
+					Time:   binary.LittleEndian.Uint32(ev[4:]),
+					RootX:  int16(binary.LittleEndian.Uint16(ev[20:])),
+					RootY:  int16(binary.LittleEndian.Uint16(ev[22:])),
+				})
+			}
+			if len(events) > 0 {
+				fn(events)
+			}
+		}
+	}
+}
+
+// dialDisplay connects to the X server named by DISPLAY, the way xgb does.
+// It returns the connection with the host and display number used to look
+// up its authorization. For TCP the host is the address connected to.
+func dialDisplay() (conn net.Conn, host, display string, err error) {
+	network, address, host, display, err := parseDisplay(os.Getenv("DISPLAY"))
+	if err != nil {
+		return nil, "", "", err
+	}
+	conn, err = net.Dial(network, address)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to connect to X server: %w", err)
+	}
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		host = addr.IP.String()
+	}
+	return conn, host, display, nil
+}
+
+// parseDisplay splits a display name such as ":0", "host:1.0" or
+// "/path/to/socket:0" into the network and address to dial, and the host
+// and display number the connection is authorized for. host is empty for
+// local connections.
+func parseDisplay(name string) (network, address, host, display string, err error) {
+	colon := strings.LastIndex(name, ":")
+	if colon < 0 {
+		return "", "", "", "", fmt.Errorf("bad display string %q", name)
+	}
+
+	host, display = name[:colon], name[colon+1:]
+	if dot := strings.LastIndex(display, "."); dot >= 0 {
+		display = display[:dot]
+	}
+	number, err := strconv.Atoi(display)
+	if err != nil || number < 0 {
+		return "", "", "", "", fmt.Errorf("bad display string %q", name)
+	}
+
+	protocol := "tcp"
+	if slash := strings.LastIndex(host, "/"); slash >= 0 && host[0] != '/' {
+		protocol, host = host[:slash], host[slash+1:]
+	}
+
+	switch {
+	case strings.HasPrefix(host, "/"):
+		return "unix", host + ":" + display, "", display, nil
+	case host != "" && host != "unix":
+		return protocol, net.JoinHostPort(host, strconv.Itoa(6000+number)), host, display, nil
+	default:
+		return "unix", "/tmp/.X11-unix/X" + display, "", display, nil
+	}
+}
+
+// setupConnection performs the X connection setup on conn, authorizing
+// with the MIT-MAGIC-COOKIE-1 from the Xauthority file when there is one.
+func setupConnection(conn net.Conn, host, display string) error {
+	authName, authData := xauthCookie(host, display)
+
+	pad := func(n int) int { return (n + 3) &^ 3 }
+	req := make([]byte, 12+pad(len(authName))+pad(len(authData)))
+	req[0] = 'l' // Little-endian
+	binary.LittleEndian.PutUint16(req[2:], 11)
+	binary.LittleEndian.PutUint16(req[6:], uint16(len(authName)))
+	binary.LittleEndian.PutUint16(req[8:], uint16(len(authData)))
+	copy(req[12:], authName)
+	copy(req[12+pad(len(authName)):], authData)
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("failed to set up X connection: %w", err)
+	}
+
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return fmt.Errorf("failed to set up X connection: %w", err)
+	}
+	rest := make([]byte, int(binary.LittleEndian.Uint16(head[6:]))*4)
+	if _, err := io.ReadFull(conn, rest); err != nil {
+		return fmt.Errorf("failed to set up X connection: %w", err)
+	}
+	if head[0] != 1 {
+		reason := rest[:min(int(head[1]), len(rest))]
+		return fmt.Errorf("X server refused the connection: %s", reason)
+	}
+	return nil
+}
+
+// xauthCookie looks up the authorization for a display in the Xauthority
+// file. Local connections, including TCP ones to a loopback address, match
+// entries for this machine's hostname; remote ones match entries for their
+// IPv4 or IPv6 address. It returns empty values when there is none, for
+// servers that authorize local users without a cookie.
+func xauthCookie(host, display string) (name string, data []byte) {
+	ip := net.ParseIP(host)
+	if host == "" || host == "localhost" || ip != nil && ip.IsLoopback() {
+		host, _ = os.Hostname()
+		ip = nil
+	}
+
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		path = os.Getenv("HOME") + "/.Xauthority"
+	}
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+
+	// Each entry is a family followed by four length-prefixed fields:
+	// address, display number, authorization name and data.
+	for len(file) >= 2 {
+		family := binary.BigEndian.Uint16(file)
+		file = file[2:]
+
+		var fields [4][]byte
+		for i := range fields {
+			if fields[i], file, err = xauthField(file); err != nil {
+				return "", nil
+			}
+		}
+
+		var addrMatch bool
+		switch family {
+		case xauthFamilyWild:
+			addrMatch = true
+		case xauthFamilyLocal:
+			addrMatch = ip == nil && string(fields[0]) == host
+		case xauthFamilyInternet:
+			addrMatch = ip.To4() != nil && net.IP(fields[0]).Equal(ip)
+		case xauthFamilyInternet6:
+			addrMatch = ip.To4() == nil && len(fields[0]) == net.IPv6len && net.IP(fields[0]).Equal(ip)
+		}
+		displayMatch := len(fields[1]) == 0 || string(fields[1]) == display
+		if addrMatch && displayMatch && string(fields[2]) == "MIT-MAGIC-COOKIE-1" {
+			return string(fields[2]), fields[3]
+		}
+	}
+	return "", nil
+}
+
+// xauthField splits one length-prefixed field off an Xauthority entry.
+func xauthField(b []byte) (field, rest []byte, err error) {
+	if len(b) < 2 {
+		return nil, nil, errors.New("truncated Xauthority entry")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return nil, nil, errors.New("truncated Xauthority entry")
+	}
+	return b[2 : 2+n], b[2+n:], nil
+}
@@ -0,0 +1,402 @@
+package overlay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jezek/xgb/xproto"
+)
+
+// recordReply encodes one RECORD reply of the given category carrying data.
+func recordReply(category byte, data []byte) []byte {
+	header := make([]byte, 32)
+	header[0] = 1
+	header[1] = category
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)/4))
+	return append(header, data...)
+}
+
+// wireEvent encodes ev as the 32 bytes the server records it as. sent sets
+// the bit marking events produced by SendEvent, which the reader masks off.
+func wireEvent(ev inputEvent, sent bool) []byte {
+	b := make([]byte, 32)
+	b[0] = ev.Type
+	if sent {
+		b[0] |= 0x80
+	}
+	b[1] = ev.Detail
+	binary.LittleEndian.PutUint32(b[4:], ev.Time)
+	binary.LittleEndian.PutUint16(b[20:], uint16(ev.RootX))
+	binary.LittleEndian.PutUint16(b[22:], uint16(ev.RootY))
+	return b
+}
+
+func TestReadRecordStream(t *testing.T) {
+	press := inputEvent{Type: xproto.KeyPress, Detail: 25, Time: 1000}
+	motion := inputEvent{Type: xproto.MotionNotify, Time: 1001, RootX: -1920, RootY: 540}
+	click := inputEvent{Type: xproto.ButtonPress, Detail: 1, Time: 1002, RootX: 10, RootY: 20}
+
+	tests := []struct {
+		name    string
+		stream  []byte
+		batches [][]inputEvent
+		err     string
+	}{
+		{
+			name: "events until end of data",
+			stream: bytes.Join([][]byte{
+				recordReply(recordFromServer, append(wireEvent(press, false), wireEvent(motion, true)...)),
+				// Start of data, and replies without events, are skipped.
+				recordReply(4, nil),
+				recordReply(recordFromServer, nil),
+				recordReply(recordFromServer, wireEvent(click, false)),
+				recordReply(recordEndOfData, nil),
+				// Nothing after the end is read.
+				recordReply(recordFromServer, wireEvent(press, false)),
+			}, nil),
+			batches: [][]inputEvent{{press, motion}, {click}},
+		},
+		{
+			name: "trailing partial event",
+			stream: bytes.Join([][]byte{
+				recordReply(recordFromServer, append(wireEvent(click, false), 0, 0, 0, 0)),
+				recordReply(recordEndOfData, nil),
+			}, nil),
+			batches: [][]inputEvent{{click}},
+		},
+		{
+			name:   "X error",
+			stream: append([]byte{0, 2}, make([]byte, 30)...),
+			err:    "X error 2",
+		},
+		{
+			name: "reply too large",
+			stream: func() []byte {
+				header := recordReply(recordFromServer, nil)
+				binary.LittleEndian.PutUint32(header[4:], maxRecordReply/4+1)
+				return header
+			}(),
+			err: "too large",
+		},
+		{
+			name:   "truncated reply",
+			stream: recordReply(recordFromServer, wireEvent(press, false))[:48],
+			err:    io.ErrUnexpectedEOF.Error(),
+		},
+		{
+			name:    "connection closed",
+			stream:  recordReply(recordFromServer, wireEvent(press, false)),
+			batches: [][]inputEvent{{press}},
+			err:     io.EOF.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var batches [][]inputEvent
+			err := readRecordStream(bytes.NewReader(tt.stream), func(events []inputEvent) {
+				batches = append(batches, events)
+			})
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+			if !reflect.DeepEqual(batches, tt.batches) {
+				t.Errorf("batches = %+v, want %+v", batches, tt.batches)
+			}
+		})
+	}
+}
+
+// xauthEntry encodes one Xauthority entry.
+func xauthEntry(family uint16, addr, display, name string, data []byte) []byte {
+	b := binary.BigEndian.AppendUint16(nil, family)
+	for _, field := range [][]byte{[]byte(addr), []byte(display), []byte(name), data} {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(field)))
+		b = append(b, field...)
+	}
+	return b
+}
+
+func TestXauthCookie(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const cookie = "MIT-MAGIC-COOKIE-1"
+	remote4 := string(net.ParseIP("192.0.2.7").To4())
+	remote6 := string(net.ParseIP("2001:db8::7"))
+
+	tests := []struct {
+		name    string
+		file    []byte
+		host    string
+		display string
+		want    string
+	}{
+		{
+			name: "local entry for this display",
+			file: bytes.Join([][]byte{
+				xauthEntry(xauthFamilyLocal, hostname, "1", cookie, []byte("one")),
+				xauthEntry(xauthFamilyLocal, hostname, "0", cookie, []byte("zero")),
+			}, nil),
+			display: "0",
+			want:    "zero",
+		},
+		{
+			name:    "localhost is this machine",
+			file:    xauthEntry(xauthFamilyLocal, hostname, "0", cookie, []byte("zero")),
+			host:    "localhost",
+			display: "0",
+			want:    "zero",
+		},
+		{
+			name:    "loopback address is this machine",
+			file:    xauthEntry(xauthFamilyLocal, hostname, "0", cookie, []byte("zero")),
+			host:    "127.0.0.1",
+			display: "0",
+			want:    "zero",
+		},
+		{
+			name:    "local entry for another machine",
+			file:    xauthEntry(xauthFamilyLocal, hostname+"-other", "0", cookie, []byte("zero")),
+			display: "0",
+		},
+		{
+			name:    "wildcard without display number",
+			file:    xauthEntry(xauthFamilyWild, "", "", cookie, []byte("any")),
+			display: "3",
+			want:    "any",
+		},
+		{
+			name: "other authorization schemes are skipped",
+			file: bytes.Join([][]byte{
+				xauthEntry(xauthFamilyLocal, hostname, "0", "XDM-AUTHORIZATION-1", []byte("xdm")),
+				xauthEntry(xauthFamilyLocal, hostname, "0", cookie, []byte("zero")),
+			}, nil),
+			display: "0",
+			want:    "zero",
+		},
+		{
+			name: "IPv4 address",
+			file: bytes.Join([][]byte{
+				xauthEntry(xauthFamilyLocal, hostname, "0", cookie, []byte("local")),
+				xauthEntry(xauthFamilyInternet, remote4, "0", cookie, []byte("remote")),
+			}, nil),
+			host:    "192.0.2.7",
+			display: "0",
+			want:    "remote",
+		},
+		{
+			name:    "IPv4 entry for another address",
+			file:    xauthEntry(xauthFamilyInternet, remote4, "0", cookie, []byte("remote")),
+			host:    "192.0.2.8",
+			display: "0",
+		},
+		{
+			name:    "IPv6 address",
+			file:    xauthEntry(xauthFamilyInternet6, remote6, "0", cookie, []byte("remote6")),
+			host:    "2001:db8::7",
+			display: "0",
+			want:    "remote6",
+		},
+		{
+			name:    "truncated entry",
+			file:    xauthEntry(xauthFamilyLocal, hostname, "0", cookie, []byte("zero"))[:12],
+			display: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Xauthority")
+			if err := os.WriteFile(path, tt.file, 0600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("XAUTHORITY", path)
+
+			name, data := xauthCookie(tt.host, tt.display)
+			if tt.want == "" {
+				if name != "" || data != nil {
+					t.Errorf("xauthCookie = %q %q, want none", name, data)
+				}
+				return
+			}
+			if name != cookie || string(data) != tt.want {
+				t.Errorf("xauthCookie = %q %q, want %q %q", name, data, cookie, tt.want)
+			}
+		})
+	}
+}
+
+func TestXauthCookieNoFile(t *testing.T) {
+	t.Setenv("XAUTHORITY", filepath.Join(t.TempDir(), "missing"))
+	if name, data := xauthCookie("", "0"); name != "" || data != nil {
+		t.Errorf("xauthCookie = %q %q, want none", name, data)
+	}
+}
+
+func TestXauthField(t *testing.T) {
+	tests := []struct {
+		in    []byte
+		field string
+		rest  string
+		err   bool
+	}{
+		{in: []byte{0, 3, 'a', 'b', 'c', 'd'}, field: "abc", rest: "d"},
+		{in: []byte{0, 0, 'x'}, field: "", rest: "x"},
+		{in: []byte{0}, err: true},
+		{in: []byte{0, 4, 'a', 'b'}, err: true},
+	}
+
+	for _, tt := range tests {
+		field, rest, err := xauthField(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("xauthField(%v) succeeded, want an error", tt.in)
+			}
+			continue
+		}
+		if err != nil || string(field) != tt.field || string(rest) != tt.rest {
+			t.Errorf("xauthField(%v) = %q %q %v, want %q %q", tt.in, field, rest, err, tt.field, tt.rest)
+		}
+	}
+}
+
+func TestParseDisplay(t *testing.T) {
+	tests := []struct {
+		name                            string
+		network, address, host, display string
+		err                             bool
+	}{
+		{name: ":0", network: "unix", address: "/tmp/.X11-unix/X0", display: "0"},
+		{name: ":1.2", network: "unix", address: "/tmp/.X11-unix/X1", display: "1"},
+		{name: "unix:0", network: "unix", address: "/tmp/.X11-unix/X0", display: "0"},
+		{name: "/run/x/sock:3", network: "unix", address: "/run/x/sock:3", display: "3"},
+		{name: "example.org:10.0", network: "tcp", address: "example.org:6010", host: "example.org", display: "10"},
+		{name: "tcp/10.0.0.2:1", network: "tcp", address: "10.0.0.2:6001", host: "10.0.0.2", display: "1"},
+		{name: "tcp6/2001:db8::7:0", network: "tcp6", address: "[2001:db8::7]:6000", host: "2001:db8::7", display: "0"},
+		{name: "", err: true},
+		{name: "localhost", err: true},
+		{name: ":x", err: true},
+		{name: ":-1", err: true},
+	}
+
+	for _, tt := range tests {
+		network, address, host, display, err := parseDisplay(tt.name)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseDisplay(%q) succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil || network != tt.network || address != tt.address || host != tt.host || display != tt.display {
+			t.Errorf("parseDisplay(%q) = %q %q %q %q %v, want %q %q %q %q",
+				tt.name, network, address, host, display, err, tt.network, tt.address, tt.host, tt.display)
+		}
+	}
+}
+
+func TestDialDisplay(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "X")
+	ln, err := net.Listen("unix", socket+":5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	t.Setenv("DISPLAY", socket+":5.0")
+	conn, host, display, err := dialDisplay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if host != "" || display != "5" {
+		t.Errorf("dialDisplay = %q %q, want local display 5", host, display)
+	}
+
+	t.Setenv("DISPLAY", socket+":6")
+	if _, _, _, err := dialDisplay(); err == nil {
+		t.Errorf("dialDisplay without a server = %v, want a connection error", err)
+	}
+}
+
+func TestIsAutoRepeat(t *testing.T) {
+	release := func(code byte, time uint32) inputEvent {
+		return inputEvent{Type: xproto.KeyRelease, Detail: code, Time: time}
+	}
+	press := func(code byte, time uint32) inputEvent {
+		return inputEvent{Type: xproto.KeyPress, Detail: code, Time: time}
+	}
+
+	tests := []struct {
+		name   string
+		prev   inputEvent
+		events []inputEvent
+		want   []bool
+	}{
+		{
+			name:   "repeat pair",
+			events: []inputEvent{press(25, 100), release(25, 600), press(25, 600)},
+			want:   []bool{false, true, true},
+		},
+		{
+			name:   "real release and press",
+			events: []inputEvent{release(25, 600), press(25, 650)},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "different keys at one time",
+			events: []inputEvent{release(25, 600), press(26, 600)},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "release ending a batch",
+			events: []inputEvent{press(25, 100), release(25, 600)},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "pair split across batches",
+			prev:   release(25, 600),
+			events: []inputEvent{press(25, 600), release(25, 900)},
+			want:   []bool{true, false},
+		},
+		{
+			name:   "press after a different previous batch",
+			prev:   release(25, 500),
+			events: []inputEvent{press(25, 600)},
+			want:   []bool{false},
+		},
+		{
+			name:   "press after a press",
+			events: []inputEvent{press(25, 600), press(25, 600)},
+			want:   []bool{false, false},
+		},
+		{
+			name: "button events are never repeats",
+			events: []inputEvent{
+				{Type: xproto.ButtonRelease, Detail: 1, Time: 600},
+				{Type: xproto.ButtonPress, Detail: 1, Time: 600},
+			},
+			want: []bool{false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]bool, len(tt.events))
+			for i := range tt.events {
+				got[i] = isAutoRepeat(tt.prev, tt.events, i)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("isAutoRepeat = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
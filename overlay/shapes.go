@@ -173,6 +173,30 @@ func GenerateCrossDot(centerX, centerY, size, thickness, gap, dotSize int16) []x
 	return rects
 }
 
+// GenerateHitmarker creates a diagonal X around the center: four arms of
+// length size, starting gap pixels from the center along each diagonal.
+func GenerateHitmarker(centerX, centerY, size, thickness, gap int16) []xproto.Rectangle {
+	if size <= 0 {
+		return nil
+	}
+
+	half := thickness / 2
+	rects := make([]xproto.Rectangle, 0, 4*size)
+
+	for d := gap; d < gap+size; d++ {
+		for _, dir := range [][2]int16{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			rects = append(rects, xproto.Rectangle{
+				X:      centerX + dir[0]*d - half,
+				Y:      centerY + dir[1]*d - half,
+				Width:  uint16(thickness),
+				Height: uint16(thickness),
+			})
+		}
+	}
+
+	return rects
+}
+
 // GenerateOutline creates outline rectangles around the given shape.
 // It generates a larger version of the shape that will be drawn behind the main shape.
 func GenerateOutline(rects []xproto.Rectangle, outlineThickness int16) []xproto.Rectangle {
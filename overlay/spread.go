@@ -0,0 +1,122 @@
+package overlay
+
+import (
+	"math"
+	"time"
+
+	"gocrosshair/config"
+)
+
+// spread animates how far the crosshair opens while a trigger, such as the
+// left mouse button or a movement key, is held. It opens over the ramp time
+// and closes over the recover time, starting from wherever it was when the
+// trigger changed.
+type spread struct {
+	held  bool
+	from  float64   // Progress when held last changed, from 0 to 1
+	since time.Time // When held last changed
+}
+
+// progress returns how far open the spread is at now, from 0 to 1.
+func (s *spread) progress(now time.Time, ramp, recover int) float64 {
+	elapsed := float64(now.Sub(s.since)) / float64(time.Millisecond)
+
+	if s.held {
+		if ramp <= 0 {
+			return 1
+		}
+		return min(1, s.from+elapsed/float64(ramp))
+	}
+	if recover <= 0 {
+		return 0
+	}
+	return max(0, s.from-elapsed/float64(recover))
+}
+
+// set records whether the trigger is held at now.
+func (s *spread) set(held bool, now time.Time, ramp, recover int) {
+	if held == s.held {
+		return
+	}
+	s.from = s.progress(now, ramp, recover)
+	s.since = now
+	s.held = held
+}
+
+// moving reports whether a spread at progress p is still opening or closing.
+func (s *spread) moving(p float64) bool {
+	return (s.held && p < 1) || (!s.held && p > 0)
+}
+
+// spreadPixels scales progress p on curve to a spread of up to full pixels.
+func spreadPixels(p float64, curve string, full int) int16 {
+	if curve == config.CurveSmooth {
+		p = p * p * (3 - 2*p)
+	}
+	return int16(math.Round(p * float64(full)))
+}
+
+// dimensions returns the size and gap to draw the crosshair with: the
+// configured values widened by the current spreads. Firing moves the arms
+// outwards with the gap, so they keep their length; moving also makes them
+// longer.
+func (o *Overlay) dimensions() (size, gap int16) {
+	cfg := o.config.Crosshair
+	return int16(cfg.Size) + o.fireGap/2 + o.moveGap, int16(cfg.Gap) + o.fireGap + o.moveGap
+}
+
+// maxDimensions returns the dimensions at full spread.
+func (o *Overlay) maxDimensions() (size, gap int16) {
+	cfg := o.config.Crosshair
+	fire, move := int16(o.config.Mouse.Spread), int16(o.config.Movement.Spread)
+	return int16(cfg.Size) + fire/2 + move, int16(cfg.Gap) + fire + move
+}
+
+// updateSpread advances the fire and movement spreads, redrawing the
+// crosshair when its dimensions change. It reports whether either spread is
+// still moving. The caller must hold o.mu.
+func (o *Overlay) updateSpread(firing, moving bool, now time.Time) bool {
+	m, mv := o.config.Mouse, o.config.Movement
+	o.fire.set(firing, now, m.SpreadRamp, m.SpreadRecover)
+	o.move.set(moving, now, mv.SpreadRamp, mv.SpreadRecover)
+
+	fp := o.fire.progress(now, m.SpreadRamp, m.SpreadRecover)
+	mp := o.move.progress(now, mv.SpreadRamp, mv.SpreadRecover)
+
+	fireGap := spreadPixels(fp, config.CurveLinear, m.Spread)
+	moveGap := spreadPixels(mp, mv.Curve, mv.Spread)
+	if fireGap != o.fireGap || moveGap != o.moveGap {
+		o.fireGap, o.moveGap = fireGap, moveGap
+		o.redraw()
+	}
+
+	return (m.Spread > 0 && o.fire.moving(fp)) || (mv.Spread > 0 && o.move.moving(mp))
+}
+
+// updateMovementKeys looks up the keycodes of the movement keys.
+// The caller must hold o.mu.
+func (o *Overlay) updateMovementKeys() {
+	o.moveKeys = nil
+	if o.config.Movement.Spread <= 0 {
+		return
+	}
+
+	mapping, first, err := o.keyboardMapping()
+	if err != nil {
+		return
+	}
+	for _, sym := range o.config.Movement.Keysyms() {
+		o.moveKeys = append(o.moveKeys, keycodesFor(mapping, first, sym)...)
+	}
+}
+
+// movementHeld reports whether any movement key is held.
+// The caller must hold o.mu.
+func (o *Overlay) movementHeld() bool {
+	for _, code := range o.moveKeys {
+		if o.keysHeld[code] {
+			return true
+		}
+	}
+	return false
+}
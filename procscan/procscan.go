@@ -0,0 +1,82 @@
+// Package procscan lists running processes by reading a proc filesystem.
+// The root is a parameter, so a directory laid out like /proc can stand in
+// for it.
+package procscan
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultRoot is where the proc filesystem is mounted.
+const DefaultRoot = "/proc"
+
+// Process describes one running process.
+type Process struct {
+	PID     int
+	Exe     string // Base name of the executable, or the command name if it cannot be read
+	Cmdline string // Arguments joined with spaces
+}
+
+// Scanner reads processes from the proc filesystem at Root.
+type Scanner struct {
+	Root string
+}
+
+// New returns a scanner for the proc filesystem at root, or at DefaultRoot
+// when root is empty.
+func New(root string) *Scanner {
+	if root == "" {
+		root = DefaultRoot
+	}
+	return &Scanner{Root: root}
+}
+
+// Processes returns every process that can be read. Processes that exit
+// while being read, and those of other users whose details are hidden, are
+// skipped or returned with the details that could be read.
+func (s *Scanner) Processes() ([]Process, error) {
+	entries, err := os.ReadDir(s.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	var procs []Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid <= 0 {
+			continue
+		}
+		if p, ok := s.Process(pid); ok {
+			procs = append(procs, p)
+		}
+	}
+	return procs, nil
+}
+
+// Process reads one process. It reports false when the process does not
+// exist.
+func (s *Scanner) Process(pid int) (Process, bool) {
+	dir := filepath.Join(s.Root, strconv.Itoa(pid))
+	p := Process{PID: pid}
+
+	if path, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		p.Exe = filepath.Base(strings.TrimSuffix(path, " (deleted)"))
+	} else if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		p.Exe = strings.TrimSpace(string(comm))
+	} else {
+		return Process{}, false
+	}
+
+	// The arguments are NUL-terminated.
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		args := bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0})
+		p.Cmdline = string(bytes.Join(args, []byte{' '}))
+	}
+
+	return p, true
+}
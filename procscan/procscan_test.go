@@ -0,0 +1,75 @@
+package procscan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeProc lays out one process below root. exe is the target of the exe
+// link, or "" to leave it out as for processes of other users.
+func writeProc(t *testing.T, root, pid, exe, comm, cmdline string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if exe != "" {
+		if err := os.Symlink(exe, filepath.Join(dir, "exe")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if comm != "" {
+		if err := os.WriteFile(filepath.Join(dir, "comm"), []byte(comm+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	root := t.TempDir()
+	writeProc(t, root, "100", "/games/cs2/linuxsteamrt64/cs2", "cs2", "/games/cs2/cs2\x00-novid\x00+fps_max 0\x00")
+	// The link of a replaced binary is marked as deleted.
+	writeProc(t, root, "200", "/usr/bin/quake (deleted)", "quake", "quake\x00")
+	// Without access to exe, the command name is used.
+	writeProc(t, root, "300", "", "wine64-preload", "C:\\Games\\game.exe\x00")
+	// A process that exited while being read has neither.
+	writeProc(t, root, "400", "", "", "")
+	// Entries that are not processes are skipped.
+	if err := os.MkdirAll(filepath.Join(root, "sys"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "uptime"), []byte("1.0 1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := New(root).Processes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Process{
+		{PID: 100, Exe: "cs2", Cmdline: "/games/cs2/cs2 -novid +fps_max 0"},
+		{PID: 200, Exe: "quake", Cmdline: "quake"},
+		{PID: 300, Exe: "wine64-preload", Cmdline: "C:\\Games\\game.exe"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Processes() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestProcessMissing(t *testing.T) {
+	if p, ok := New(t.TempDir()).Process(42); ok {
+		t.Errorf("Process(42) = %+v, want none", p)
+	}
+}
+
+func TestProcessesNoRoot(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing")).Processes(); err == nil {
+		t.Error("Processes() succeeded without a proc filesystem")
+	}
+}
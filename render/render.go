@@ -0,0 +1,256 @@
+// Package render rasterizes crosshair geometry to images without an X server.
+// It uses the same shape generators as the overlay, so the output matches the
+// pixels that would appear on screen.
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/jezek/xgb/xproto"
+
+	"gocrosshair/config"
+	"gocrosshair/overlay"
+)
+
+// Background options for rendered images.
+const (
+	BackgroundTransparent = "transparent"
+	BackgroundChecker     = "checker"
+)
+
+// checkerSize is the size of a checkerboard tile in output pixels.
+const checkerSize = 8
+
+var (
+	checkerLight = color.RGBA{R: 0xCC, G: 0xCC, B: 0xCC, A: 0xFF}
+	checkerDark  = color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xFF}
+)
+
+// Options controls how a crosshair is rendered.
+type Options struct {
+	// Scale is the number of output pixels per screen pixel.
+	Scale int
+	// Background is "transparent", "checker" or a hex color.
+	Background string
+	// Padding is the number of empty screen pixels around the crosshair.
+	Padding int
+}
+
+// DefaultOptions returns the options used when none are specified.
+func DefaultOptions() Options {
+	return Options{
+		Scale:      1,
+		Background: BackgroundTransparent,
+		Padding:    2,
+	}
+}
+
+// Validate checks that the options can be rendered.
+func (o Options) Validate() error {
+	if o.Scale < 1 || o.Scale > 64 {
+		return fmt.Errorf("scale must be between 1 and 64 (got %d)", o.Scale)
+	}
+	if o.Padding < 0 {
+		return fmt.Errorf("padding must not be negative (got %d)", o.Padding)
+	}
+	switch o.Background {
+	case BackgroundTransparent, BackgroundChecker:
+	default:
+		if _, err := config.ParseColor(o.Background); err != nil {
+			return fmt.Errorf("invalid background %q (must be %q, %q or a hex color)",
+				o.Background, BackgroundTransparent, BackgroundChecker)
+		}
+	}
+	return nil
+}
+
+// Geometry holds the crosshair rectangles in screen pixels, centered on (0, 0).
+type Geometry struct {
+	Shape   []xproto.Rectangle
+	Outline []xproto.Rectangle
+	Bounds  image.Rectangle
+}
+
+// NewGeometry generates the crosshair and outline rectangles for cfg.
+func NewGeometry(cfg *config.Config) Geometry {
+	c := cfg.Crosshair
+
+	shapeRects := overlay.GenerateShape(
+		c.Shape,
+		0, 0,
+		int16(c.Size),
+		int16(c.Thickness),
+		int16(c.Gap),
+	)
+
+	var outlineRects []xproto.Rectangle
+	if c.OutlineThickness > 0 {
+		outlineRects = overlay.GenerateOutline(shapeRects, int16(c.OutlineThickness))
+	}
+
+	var bounds image.Rectangle
+	for _, r := range append(append([]xproto.Rectangle{}, outlineRects...), shapeRects...) {
+		bounds = bounds.Union(rectToImage(r))
+	}
+
+	return Geometry{
+		Shape:   shapeRects,
+		Outline: outlineRects,
+		Bounds:  bounds,
+	}
+}
+
+// Rasterize draws the crosshair into an image at 1:1 scale with a transparent
+// background. The image origin is the top-left of the padded bounding box.
+func Rasterize(cfg *config.Config, padding int) *image.RGBA {
+	geom := NewGeometry(cfg)
+	bounds := geom.Bounds.Inset(-padding)
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	fill := func(rects []xproto.Rectangle, c color.RGBA) {
+		for _, r := range rects {
+			ir := rectToImage(r).Sub(bounds.Min)
+			for y := ir.Min.Y; y < ir.Max.Y; y++ {
+				for x := ir.Min.X; x < ir.Max.X; x++ {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+
+	fill(geom.Outline, toRGBA(cfg.GetOutlineColorUint32()))
+	fill(geom.Shape, toRGBA(cfg.GetColorUint32()))
+
+	return img
+}
+
+// Image renders the crosshair with the given options applied.
+func Image(cfg *config.Config, opts Options) (*image.RGBA, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	src := Rasterize(cfg, opts.Padding)
+	sb := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, sb.Dx()*opts.Scale, sb.Dy()*opts.Scale))
+
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			px := src.RGBAAt(x/opts.Scale, y/opts.Scale)
+			if px.A == 0 {
+				px = backgroundAt(opts.Background, x, y)
+			}
+			dst.SetRGBA(x, y, px)
+		}
+	}
+
+	return dst, nil
+}
+
+// WritePNG encodes the rendered crosshair as a PNG image.
+func WritePNG(w io.Writer, cfg *config.Config, opts Options) error {
+	img, err := Image(cfg, opts)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return nil
+}
+
+// WriteSVG writes the rendered crosshair as an SVG document. Every rectangle
+// is emitted as-is, so the drawing is pixel-exact at any zoom level.
+func WriteSVG(w io.Writer, cfg *config.Config, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	geom := NewGeometry(cfg)
+	bounds := geom.Bounds.Inset(-opts.Padding)
+	width := bounds.Dx() * opts.Scale
+	height := bounds.Dy() * opts.Scale
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%d %d %d %d" shape-rendering="crispEdges">`+"\n",
+		width, height, bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy())
+
+	switch opts.Background {
+	case BackgroundTransparent:
+	case BackgroundChecker:
+		tile := float64(checkerSize) / float64(opts.Scale)
+		fmt.Fprintf(&b, `  <defs><pattern id="checker" x="%d" y="%d" width="%g" height="%g" patternUnits="userSpaceOnUse">`,
+			bounds.Min.X, bounds.Min.Y, tile*2, tile*2)
+		fmt.Fprintf(&b, `<rect width="%g" height="%g" fill="%s"/>`, tile*2, tile*2, hexColor(checkerLight))
+		fmt.Fprintf(&b, `<rect x="%g" width="%g" height="%g" fill="%s"/>`, tile, tile, tile, hexColor(checkerDark))
+		fmt.Fprintf(&b, `<rect y="%g" width="%g" height="%g" fill="%s"/>`, tile, tile, tile, hexColor(checkerDark))
+		b.WriteString("</pattern></defs>\n")
+		fmt.Fprintf(&b, `  <rect x="%d" y="%d" width="%d" height="%d" fill="url(#checker)"/>`+"\n",
+			bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy())
+	default:
+		bg, _ := config.ParseColor(opts.Background)
+		fmt.Fprintf(&b, `  <rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+			bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy(), hexColor(toRGBA(bg)))
+	}
+
+	writeGroup := func(rects []xproto.Rectangle, fill uint32) {
+		if len(rects) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, `  <g fill="%s">`+"\n", hexColor(toRGBA(fill)))
+		for _, r := range rects {
+			fmt.Fprintf(&b, `    <rect x="%d" y="%d" width="%d" height="%d"/>`+"\n", r.X, r.Y, r.Width, r.Height)
+		}
+		b.WriteString("  </g>\n")
+	}
+
+	writeGroup(geom.Outline, cfg.GetOutlineColorUint32())
+	writeGroup(geom.Shape, cfg.GetColorUint32())
+	b.WriteString("</svg>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write SVG: %w", err)
+	}
+	return nil
+}
+
+// backgroundAt returns the background color for an output pixel.
+func backgroundAt(background string, x, y int) color.RGBA {
+	switch background {
+	case BackgroundTransparent:
+		return color.RGBA{}
+	case BackgroundChecker:
+		if (x/checkerSize+y/checkerSize)%2 == 0 {
+			return checkerLight
+		}
+		return checkerDark
+	default:
+		bg, _ := config.ParseColor(background)
+		return toRGBA(bg)
+	}
+}
+
+// rectToImage converts an X rectangle to an image rectangle.
+func rectToImage(r xproto.Rectangle) image.Rectangle {
+	return image.Rect(int(r.X), int(r.Y), int(r.X)+int(r.Width), int(r.Y)+int(r.Height))
+}
+
+// toRGBA converts a 0xRRGGBB value to an opaque color.
+func toRGBA(c uint32) color.RGBA {
+	return color.RGBA{
+		R: uint8(c >> 16),
+		G: uint8(c >> 8),
+		B: uint8(c),
+		A: 0xFF,
+	}
+}
+
+// hexColor formats a color as #RRGGBB.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
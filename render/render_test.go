@@ -0,0 +1,58 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gocrosshair/config"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got byte for byte with testdata/name, or rewrites the
+// file when the test runs with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (run go test -update after checking the change)", name)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	for _, shape := range config.ValidShapes {
+		cfg := config.Default()
+		cfg.Crosshair.Shape = shape
+		opts := DefaultOptions()
+		opts.Scale = 2
+		opts.Background = "checker"
+
+		t.Run(shape, func(t *testing.T) {
+			var png, svg bytes.Buffer
+			if err := WritePNG(&png, cfg, opts); err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteSVG(&svg, cfg, opts); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, shape+".png", png.Bytes())
+			checkGolden(t, shape+".svg", svg.Bytes())
+		})
+	}
+}
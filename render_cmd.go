@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gocrosshair/config"
+	"gocrosshair/render"
+)
+
+// runRender implements the "render" subcommand, which writes the configured
+// crosshair to a PNG or SVG file without connecting to the X server.
+func runRender(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to configuration file (default: ~/.config/gocrosshair/config.toml)")
+	profile := fs.String("profile", "", "Profile to render (default: active_profile from the config)")
+	out := fs.String("out", "", "Output file (.png or .svg)")
+	scale := fs.Int("scale", 1, "Output pixels per screen pixel")
+	background := fs.String("background", render.BackgroundTransparent, `Background: "transparent", "checker" or a hex color`)
+	padding := fs.Int("padding", 2, "Empty screen pixels around the crosshair")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s render --out FILE [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Render the configured crosshair to a PNG or SVG image.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fs.Usage()
+		return fmt.Errorf("--out is required")
+	}
+
+	cfgPath := *configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+
+	cfg, err := loadConfigForRender(cfgPath)
+	if err != nil {
+		return err
+	}
+
+	if cfg, err = cfg.Active(*profile); err != nil {
+		return err
+	}
+
+	opts := render.Options{
+		Scale:      *scale,
+		Background: *background,
+		Padding:    *padding,
+	}
+
+	// Everything is checked and rendered before the output file is opened,
+	// so a mistake never touches an existing file.
+	var write func(io.Writer, *config.Config, render.Options) error
+	switch ext := strings.ToLower(filepath.Ext(*out)); ext {
+	case ".png":
+		write = render.WritePNG
+	case ".svg":
+		write = render.WriteSVG
+	default:
+		return fmt.Errorf("unsupported output format %q (use .png or .svg)", ext)
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, cfg, opts); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	fmt.Printf("✓ Rendered crosshair to %s\n", *out)
+	return nil
+}
+
+// loadConfigForRender loads the config at path, falling back to defaults when
+// the file does not exist. Unlike the overlay, it never writes a new file.
+func loadConfigForRender(path string) (*config.Config, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return config.Default(), nil
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n  - %w", err)
+	}
+
+	return cfg, nil
+}
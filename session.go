@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gocrosshair/config"
+	"gocrosshair/overlay"
+)
+
+// session tracks what the running overlay shows: the profile in use plus
+// the changes made with hotkeys since launch, which apply on top of any
+// profile. mu serializes the callbacks that change it, which come from the
+// overlay's event goroutine and from the goroutine reading input events.
+type session struct {
+	mu      sync.Mutex
+	overlay *overlay.Overlay
+	base    *config.Config        // Validated configuration with all profiles
+	profile string                // Profile in use, "" for the base settings
+	current *config.Config        // Configuration applied last
+	adjust  map[string]int        // Hotkey changes to KindInt fields, by key
+	color   string                // Color chosen with next_color, "" for the profile's
+	toggled bool                  // Crosshair switched off with the toggle hotkey
+	aiming  bool                  // Right button held with mouse.aim naming a profile
+	focused overlay.FocusedWindow // Window that has the focus, for trigger keys
+}
+
+// newSession returns a session for an overlay that shows cfg, the resolved
+// configuration of the named profile.
+func newSession(o *overlay.Overlay, base *config.Config, profile string, cfg *config.Config) *session {
+	return &session{
+		overlay: o,
+		base:    base,
+		profile: profile,
+		current: cfg,
+		adjust:  make(map[string]int),
+	}
+}
+
+// use switches to the named profile unless it is already in use.
+func (s *session) use(name string) error {
+	if name == s.profile {
+		return nil
+	}
+	s.profile = name
+	return s.apply()
+}
+
+// apply resolves the profile in use, or the aim profile while aiming, adds
+// the hotkey changes and shows the result. Changes that would leave a
+// setting out of range are clamped.
+func (s *session) apply() error {
+	name := s.profile
+	if s.aiming {
+		name = s.base.Mouse.Aim
+	}
+
+	cfg, err := s.base.Resolve(name)
+	if err != nil {
+		return err
+	}
+
+	for key, delta := range s.adjust {
+		f, _ := config.LookupField(key)
+		val := min(max(f.Int(cfg)+delta, f.Min), f.Max)
+		_ = f.Set(cfg, strconv.Itoa(val))
+	}
+	if s.color != "" {
+		cfg.Crosshair.Color = s.color
+	}
+
+	if err := s.overlay.Apply(cfg); err != nil {
+		return err
+	}
+	s.current = cfg
+	return nil
+}
+
+// bindings returns the key bindings of the [keys] table, in action order.
+func bindings(cfg *config.Config) []overlay.KeyBinding {
+	var out []overlay.KeyBinding
+	for _, action := range config.ValidActions {
+		if chord, err := config.ParseChord(cfg.Keys[action]); err == nil {
+			out = append(out, overlay.KeyBinding{Action: action, Chord: chord})
+		}
+	}
+	return out
+}
+
+// hotkey carries out a hotkey action. Key repeat nudges and resizes the
+// crosshair again but does not repeat the other actions.
+func (s *session) hotkey(ev overlay.KeyEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ev.Action == config.ActionHold {
+		if err := s.overlay.SetHidden(overlay.HideReleased, !ev.Pressed); err != nil {
+			log.Printf("Warning: failed to update crosshair visibility: %v", err)
+		}
+		return
+	}
+	if !ev.Pressed {
+		return
+	}
+
+	var err error
+	switch ev.Action {
+	case config.ActionToggle:
+		if !ev.Repeat {
+			s.setToggled(!s.toggled)
+		}
+	case config.ActionNextProfile, config.ActionPreviousProfile:
+		if !ev.Repeat {
+			err = s.cycleProfile(ev.Action == config.ActionNextProfile)
+		}
+	case config.ActionNudgeLeft:
+		err = s.change("offset_x", -1)
+	case config.ActionNudgeRight:
+		err = s.change("offset_x", 1)
+	case config.ActionNudgeUp:
+		err = s.change("offset_y", -1)
+	case config.ActionNudgeDown:
+		err = s.change("offset_y", 1)
+	case config.ActionGrow:
+		err = s.change("size", 1)
+	case config.ActionShrink:
+		err = s.change("size", -1)
+	case config.ActionNextColor:
+		if !ev.Repeat {
+			err = s.nextColor()
+		}
+	}
+
+	if err != nil {
+		log.Printf("Warning: hotkey %s failed: %v", ev.Action, err)
+	}
+}
+
+// setToggled shows or hides the crosshair for the toggle hotkey.
+func (s *session) setToggled(off bool) {
+	s.toggled = off
+	if err := s.overlay.SetHidden(overlay.HideToggled, off); err != nil {
+		log.Printf("Warning: failed to update crosshair visibility: %v", err)
+	}
+}
+
+// cycleProfile switches to the next or previous profile in name order, with
+// the base settings before the first profile.
+func (s *session) cycleProfile(forward bool) error {
+	names := append([]string{""}, s.base.ProfileNames()...)
+	i := slices.Index(names, s.profile)
+
+	step := 1
+	if !forward {
+		step = len(names) - 1
+	}
+	next := names[(i+step)%len(names)]
+
+	if err := s.use(next); err != nil {
+		return fmt.Errorf("cannot switch to profile %q: %w", next, err)
+	}
+	if next == "" {
+		log.Printf("Switched to the base settings")
+	} else {
+		log.Printf("Switched to profile %q", next)
+	}
+	return nil
+}
+
+// change adds delta to a KindInt setting, unless that would leave its range.
+func (s *session) change(key string, delta int) error {
+	f, _ := config.LookupField(key)
+	if val := f.Int(s.current) + delta; val < f.Min || val > f.Max {
+		return nil
+	}
+	s.adjust[key] += delta
+	return s.apply()
+}
+
+// nextColor switches to the preset color after the current one.
+func (s *session) nextColor() error {
+	presets := config.ColorPresets
+	i := slices.IndexFunc(presets, func(p config.ColorPreset) bool {
+		return strings.EqualFold(p.Value, s.current.Crosshair.Color)
+	})
+	s.color = presets[(i+1)%len(presets)].Value
+	return s.apply()
+}
+
+// buttons carries out mouse.aim while the right mouse button is held.
+func (s *session) buttons(b overlay.Buttons) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch aim := s.base.Mouse.Aim; aim {
+	case "":
+	case config.AimHide:
+		if err := s.overlay.SetHidden(overlay.HideAiming, b.Aim); err != nil {
+			log.Printf("Warning: failed to update crosshair visibility: %v", err)
+		}
+	default:
+		if b.Aim == s.aiming {
+			return
+		}
+		s.aiming = b.Aim
+		if err := s.apply(); err != nil {
+			log.Printf("Warning: failed to apply profile %q: %v", aim, err)
+		}
+	}
+}
+
+// triggerKeys returns the keysyms of every profile's trigger keys.
+func triggerKeys(cfg *config.Config) []uint32 {
+	var syms []uint32
+	for _, name := range cfg.ProfileNames() {
+		for _, key := range cfg.Profiles[name].TriggerKeys() {
+			if sym, ok := config.LookupKeysym(key); ok && !slices.Contains(syms, sym) {
+				syms = append(syms, sym)
+			}
+		}
+	}
+	return syms
+}
+
+// triggerKey switches to the first profile, in name order, that has sym as
+// a trigger key and whose focus rules match the focused window.
+func (s *session) triggerKey(sym uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.focused
+	for _, name := range s.base.ProfileNames() {
+		p := s.base.Profiles[name]
+		if !p.TriggersOn(w.Class, w.Instance, w.Exe) {
+			continue
+		}
+		for _, key := range p.TriggerKeys() {
+			if keySym, _ := config.LookupKeysym(key); keySym != sym {
+				continue
+			}
+			if name == s.profile {
+				return
+			}
+			if err := s.use(name); err != nil {
+				log.Printf("Warning: failed to apply profile %q: %v", name, err)
+				return
+			}
+			log.Printf("Key %s: using profile %q", key, name)
+			return
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"gocrosshair/config"
+)
+
+// Exit codes for non-interactive setup.
+const (
+	exitSetupFailed  = 1 // The configuration could not be written
+	exitSetupInvalid = 2 // One or more answers were rejected
+	exitSetupRead    = 3 // The existing configuration could not be read
+)
+
+// repeatedFlag collects every value of a flag that may be given more than once.
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// answer is a single key=value pair from an answers file or --set flag.
+type answer struct {
+	key     string
+	value   string
+	source  string
+	section string // Table the key was given in, or "" at the top level
+}
+
+// name returns the answer's key as given, qualified with its table.
+func (a answer) name() string {
+	if a.section == "" {
+		return a.key
+	}
+	return a.section + "." + a.key
+}
+
+// setupError describes one rejected answer in the machine-readable report.
+type setupError struct {
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message"`
+}
+
+// setupReport is printed as JSON after non-interactive setup.
+type setupReport struct {
+	OK     bool         `json:"ok"`
+	Path   string       `json:"path,omitempty"`
+	Errors []setupError `json:"errors,omitempty"`
+}
+
+// runScriptedSetup applies answers from a TOML file and --set flags on top of
+// the existing configuration (or the defaults) without a TTY. Each answer is
+// checked with the same field schema the wizard uses, the result is checked
+// with config.Validate, and the file is written with config.Save.
+// The existing file is validated only after the answers are applied, so
+// they can correct it; if it cannot be parsed, nothing is written rather
+// than replacing it with the defaults.
+// It prints a JSON report to stdout and returns the process exit code.
+func runScriptedSetup(cfgPath, answersPath string, sets []string) int {
+	cfg := config.Default()
+	if _, err := os.Stat(cfgPath); err == nil {
+		if cfg, err = config.Load(cfgPath); err != nil {
+			printSetupReport(setupReport{Errors: []setupError{{Source: cfgPath, Message: err.Error()}}})
+			return exitSetupRead
+		}
+	} else if !os.IsNotExist(err) {
+		printSetupReport(setupReport{Errors: []setupError{{Source: cfgPath, Message: err.Error()}}})
+		return exitSetupRead
+	}
+
+	var answers []answer
+	var errs []setupError
+
+	if answersPath != "" {
+		fileAnswers, err := readAnswersFile(answersPath)
+		if err != nil {
+			errs = append(errs, setupError{Source: answersPath, Message: err.Error()})
+		}
+		answers = append(answers, fileAnswers...)
+	}
+
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok {
+			errs = append(errs, setupError{Source: "--set", Value: set, Message: "expected key=value"})
+			continue
+		}
+		answers = append(answers, answer{key: strings.TrimSpace(key), value: value, source: "--set"})
+	}
+
+	for _, a := range answers {
+		f, ok := config.LookupField(a.key)
+		if a.section != "" {
+			f, ok = config.LookupSectionField(a.section, a.key)
+		}
+		if !ok {
+			msg := fmt.Sprintf("unknown field (must be one of: %s)", strings.Join(config.FieldNames(), ", "))
+			if other, found := config.LookupField(a.key); found && a.section != "" {
+				msg = fmt.Sprintf("%s belongs in [%s], not [%s]", a.key, other.Section, a.section)
+			}
+			errs = append(errs, setupError{Field: a.name(), Value: a.value, Source: a.source, Message: msg})
+			continue
+		}
+		if err := f.Set(cfg, a.value); err != nil {
+			errs = append(errs, setupError{Field: f.Name(), Value: a.value, Source: a.source, Message: err.Error()})
+		}
+	}
+
+	if len(errs) == 0 {
+		for _, f := range config.Fields {
+			if err := f.Check(cfg); err != nil {
+				errs = append(errs, setupError{Field: f.Name(), Value: f.Value(cfg), Message: err.Error()})
+			}
+		}
+	}
+
+	// Settings that depend on each other, and profiles, are checked as a whole.
+	if len(errs) == 0 {
+		if err := cfg.Validate(); err != nil {
+			for _, msg := range strings.Split(err.Error(), "\n  - ") {
+				errs = append(errs, setupError{Message: msg})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		printSetupReport(setupReport{Errors: errs})
+		return exitSetupInvalid
+	}
+
+	if err := config.Save(cfgPath, cfg); err != nil {
+		printSetupReport(setupReport{Errors: []setupError{{Message: err.Error()}}})
+		return exitSetupFailed
+	}
+
+	printSetupReport(setupReport{OK: true, Path: cfgPath})
+	return 0
+}
+
+// readAnswersFile reads a TOML answers file. Keys may be given at the top
+// level (size = 8) or inside their usual table ([crosshair] size = 8); the
+// table is kept so that a key in the wrong one can be rejected.
+func readAnswersFile(path string) ([]answer, error) {
+	var raw map[string]any
+	if _, err := toml.DecodeFile(path, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse answers: %w", err)
+	}
+
+	var answers []answer
+	add := func(section, key string, value any) {
+		answers = append(answers, answer{key: key, value: fmt.Sprint(value), source: path, section: section})
+	}
+
+	for _, key := range sortedKeys(raw) {
+		if table, ok := raw[key].(map[string]any); ok {
+			for _, sub := range sortedKeys(table) {
+				add(key, sub, table[sub])
+			}
+			continue
+		}
+		add("", key, raw[key])
+	}
+
+	return answers, nil
+}
+
+// sortedKeys returns the keys of m in a stable order for reproducible reports.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printSetupReport writes the report as a single JSON line, to stdout on
+// success and stderr on failure.
+func printSetupReport(report setupReport) {
+	out := os.Stdout
+	if !report.OK {
+		out = os.Stderr
+	}
+	data, _ := json.Marshal(report)
+	fmt.Fprintln(out, string(data))
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gocrosshair/config"
+	"gocrosshair/procscan"
+)
+
+// overlayStopTimeout is how long a stopped overlay may take to exit before
+// it is killed.
+const overlayStopTimeout = 5 * time.Second
+
+// runWatch implements the "watch" subcommand, which starts the overlay when
+// a game process named by a profile's watch rules appears, and stops it
+// when the game exits.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to configuration file (default: ~/.config/gocrosshair/config.toml)")
+	interval := fs.Duration("interval", 2*time.Second, "How often to look for game processes")
+	procRoot := fs.String("proc", procscan.DefaultRoot, "Where the proc filesystem is mounted")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s watch [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Start the crosshair when a game named by a profile's watch_exe or\n")
+		fmt.Fprintf(os.Stderr, "watch_cmdline starts, and stop it when the game exits.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	cfgPath := *configPath
+	if cfgPath == "" {
+		cfgPath = config.GetConfigPath()
+	}
+
+	cfg, err := config.LoadValid(cfgPath)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if !cfg.HasWatchRules() {
+		return fmt.Errorf("no profile in %s sets watch_exe or watch_cmdline", cfgPath)
+	}
+
+	w := &gameWatcher{
+		cfg:     cfg,
+		scanner: procscan.New(*procRoot),
+		start: func(profile string) (*exec.Cmd, error) {
+			return startOverlay(cfgPath, profile)
+		},
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	log.Printf("Watching for games every %s. Press Ctrl+C to exit.", *interval)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		w.scan()
+		select {
+		case <-sigChan:
+			w.stopOverlay()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// gameWatcher starts and stops the overlay as games come and go.
+type gameWatcher struct {
+	cfg     *config.Config
+	scanner *procscan.Scanner
+	start   func(profile string) (*exec.Cmd, error)
+
+	overlay *exec.Cmd     // Running overlay, or nil
+	exited  chan struct{} // Closed when the overlay exits
+	game    int           // PID of the game the overlay was started for
+	skip    int           // PID of a game whose overlay was closed by hand
+}
+
+// scan looks for a game once. It stops the overlay when its game has
+// exited, and starts it for the first game found when it is not running.
+func (w *gameWatcher) scan() {
+	procs, err := w.scanner.Processes()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	if w.overlay != nil {
+		select {
+		case <-w.exited:
+			// Closed by hand, such as with -stop; not reopened for this game.
+			log.Printf("Crosshair exited; not restarting it until the game does")
+			w.overlay = nil
+			w.skip = w.game
+		default:
+		}
+	}
+
+	// The overlay's own command line names the profile, which a
+	// watch_cmdline pattern could match.
+	own := map[int]bool{os.Getpid(): true}
+	if w.overlay != nil {
+		own[w.overlay.Process.Pid] = true
+	}
+
+	var game procscan.Process
+	var profile string
+	gameRunning := false
+	for _, p := range procs {
+		if own[p.PID] {
+			continue
+		}
+		if p.PID == w.game || p.PID == w.skip {
+			gameRunning = true
+		}
+		if profile == "" {
+			if name, ok := w.cfg.MatchProcess(p.Exe, p.Cmdline); ok {
+				game, profile = p, name
+			}
+		}
+	}
+
+	if gameRunning {
+		return
+	}
+	if w.overlay != nil {
+		log.Printf("Game exited (PID %d), stopping the crosshair", w.game)
+		w.stopOverlay()
+	}
+	w.game, w.skip = 0, 0
+
+	if profile == "" {
+		return
+	}
+
+	if pid, err := readPIDFile(); err == nil && isProcessRunning(pid) {
+		// Starting another would stop the running one instead.
+		log.Printf("Found %s (PID %d), but the crosshair is already running (PID %d)", game.Exe, game.PID, pid)
+		w.game, w.skip = game.PID, game.PID
+		return
+	}
+
+	cmd, err := w.start(profile)
+	if err != nil {
+		log.Printf("Warning: failed to start the crosshair: %v", err)
+		return
+	}
+
+	log.Printf("Found %s (PID %d), started the crosshair with profile %q", game.Exe, game.PID, profile)
+	w.overlay, w.game = cmd, game.PID
+	w.exited = make(chan struct{})
+	go func(exited chan struct{}) {
+		cmd.Wait()
+		close(exited)
+	}(w.exited)
+}
+
+// stopOverlay stops the overlay, if it is running, and waits for it to exit.
+func (w *gameWatcher) stopOverlay() {
+	if w.overlay == nil {
+		return
+	}
+
+	if err := w.overlay.Process.Signal(syscall.SIGTERM); err == nil {
+		select {
+		case <-w.exited:
+		case <-time.After(overlayStopTimeout):
+			w.overlay.Process.Kill()
+			<-w.exited
+		}
+	}
+	w.overlay = nil
+}
+
+// startOverlay runs the overlay with a profile as a child process, in the
+// foreground so it can be stopped when the game exits. Its log goes to the
+// watcher's standard error.
+func startOverlay(cfgPath, profile string) (*exec.Cmd, error) {
+	cmd := exec.Command(os.Args[0], "-config", cfgPath, "-profile", profile)
+	cmd.Env = append(os.Environ(), daemonEnvVar+"=1")
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
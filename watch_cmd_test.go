@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"gocrosshair/config"
+	"gocrosshair/procscan"
+)
+
+// fakeProc is a directory laid out like /proc.
+type fakeProc struct {
+	t    *testing.T
+	root string
+}
+
+// add lays out a process with an exe link and a NUL-separated command line.
+func (p fakeProc) add(pid int, exe string, args ...string) {
+	p.t.Helper()
+	dir := filepath.Join(p.root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := os.Symlink("/usr/bin/"+exe, filepath.Join(dir, "exe")); err != nil {
+		p.t.Fatal(err)
+	}
+	var cmdline []byte
+	for _, arg := range args {
+		cmdline = append(append(cmdline, arg...), 0)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), cmdline, 0644); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// remove makes a process exit.
+func (p fakeProc) remove(pid int) {
+	p.t.Helper()
+	if err := os.RemoveAll(filepath.Join(p.root, strconv.Itoa(pid))); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// newTestWatcher returns a watcher over a fake proc tree whose overlays are
+// sleep processes, and the profiles it started them with.
+func newTestWatcher(t *testing.T) (*gameWatcher, fakeProc, *[]string) {
+	t.Helper()
+	// No crosshair started by hand is running.
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	cfgPath := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(cfgPath, []byte(`
+[profiles.cs2]
+watch_cmdline = "cs2"
+
+[profiles.quake]
+watch_exe = "quake"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadValid(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proc := fakeProc{t: t, root: t.TempDir()}
+	var started []string
+	w := &gameWatcher{
+		cfg:     cfg,
+		scanner: procscan.New(proc.root),
+		start: func(profile string) (*exec.Cmd, error) {
+			started = append(started, profile)
+			cmd := exec.Command("sleep", "60")
+			if err := cmd.Start(); err != nil {
+				return nil, err
+			}
+			return cmd, nil
+		},
+	}
+	t.Cleanup(w.stopOverlay)
+	return w, proc, &started
+}
+
+// checkStarted fails unless the overlay was started exactly with profiles.
+func checkStarted(t *testing.T, started *[]string, profiles ...string) {
+	t.Helper()
+	if len(*started) != len(profiles) {
+		t.Fatalf("started %q, want %q", *started, profiles)
+	}
+	for i := range profiles {
+		if (*started)[i] != profiles[i] {
+			t.Fatalf("started %q, want %q", *started, profiles)
+		}
+	}
+}
+
+func TestGameWatcherStartsAndStops(t *testing.T) {
+	w, proc, started := newTestWatcher(t)
+
+	// The watcher's own command line mentions the profile, but is no game.
+	proc.add(os.Getpid(), "gocrosshair", "gocrosshair", "watch", "-config", "cs2.toml")
+	w.scan()
+	checkStarted(t, started)
+
+	proc.add(4242, "cs2", "/games/cs2/cs2", "-novid")
+	w.scan()
+	checkStarted(t, started, "cs2")
+	if w.overlay == nil || w.game != 4242 {
+		t.Fatalf("overlay %v for game %d, want one for game 4242", w.overlay, w.game)
+	}
+	overlay, exited := w.overlay, w.exited
+
+	// Neither is the overlay, whose command line names the profile.
+	proc.add(overlay.Process.Pid, "gocrosshair", "gocrosshair", "-profile", "cs2")
+	w.scan()
+	checkStarted(t, started, "cs2")
+	if w.overlay != overlay {
+		t.Fatal("overlay replaced while the game runs")
+	}
+
+	proc.remove(4242)
+	w.scan()
+	if w.overlay != nil {
+		t.Fatal("overlay still running after the game exited")
+	}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("overlay process did not exit")
+	}
+	checkStarted(t, started, "cs2")
+
+	proc.remove(overlay.Process.Pid)
+	proc.add(5000, "quake", "quake", "+map", "e1m1")
+	w.scan()
+	checkStarted(t, started, "cs2", "quake")
+}
+
+func TestGameWatcherSkipsClosedOverlay(t *testing.T) {
+	w, proc, started := newTestWatcher(t)
+
+	proc.add(4242, "quake", "quake")
+	w.scan()
+	checkStarted(t, started, "quake")
+
+	// Closing the overlay by hand keeps it closed while the game runs.
+	w.overlay.Process.Kill()
+	<-w.exited
+	w.scan()
+	w.scan()
+	checkStarted(t, started, "quake")
+	if w.overlay != nil || w.skip != 4242 {
+		t.Fatalf("overlay %v skipping %d, want none skipping 4242", w.overlay, w.skip)
+	}
+
+	// Once the game exits, the next one gets a crosshair again.
+	proc.remove(4242)
+	w.scan()
+	if w.skip != 0 {
+		t.Fatalf("still skipping %d after the game exited", w.skip)
+	}
+	proc.add(4343, "quake", "quake")
+	w.scan()
+	checkStarted(t, started, "quake", "quake")
+}
@@ -0,0 +1,398 @@
+package wizard
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"gocrosshair/config"
+)
+
+// maxRecentColors is the number of recently used colors that are remembered.
+const maxRecentColors = 8
+
+// sliderWidth is the number of cells in each picker slider.
+const sliderWidth = 24
+
+// colorOptionKind tells how a color option is applied when selected.
+type colorOptionKind int
+
+const (
+	optionPreset colorOptionKind = iota
+	optionRecent
+	optionPicker
+	optionCustom
+)
+
+// colorOption is one entry in the color selection list.
+type colorOption struct {
+	name  string
+	value string
+	kind  colorOptionKind
+}
+
+// hsv is a color in the HSV model: hue in degrees, saturation and value in [0, 1].
+type hsv struct {
+	h, s, v float64
+}
+
+// rgb is an 8-bit-per-channel color.
+type rgb struct {
+	r, g, b uint8
+}
+
+// parseRGB parses any color accepted by config.ParseColor.
+func parseRGB(s string) (rgb, bool) {
+	val, err := config.ParseColor(s)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{uint8(val >> 16), uint8(val >> 8), uint8(val)}, true
+}
+
+// hex formats the color as #RRGGBB.
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.r, c.g, c.b)
+}
+
+// toHSV converts the color to HSV.
+func (c rgb) toHSV() hsv {
+	r, g, b := float64(c.r)/255, float64(c.g)/255, float64(c.b)/255
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	delta := maxC - minC
+
+	var h float64
+	switch {
+	case delta == 0:
+		h = 0
+	case maxC == r:
+		h = 60 * math.Mod((g-b)/delta, 6)
+	case maxC == g:
+		h = 60 * ((b-r)/delta + 2)
+	default:
+		h = 60 * ((r-g)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+
+	var s float64
+	if maxC > 0 {
+		s = delta / maxC
+	}
+
+	return hsv{h: h, s: s, v: maxC}
+}
+
+// toRGB converts the color to 8-bit RGB.
+func (c hsv) toRGB() rgb {
+	chroma := c.v * c.s
+	x := chroma * (1 - math.Abs(math.Mod(c.h/60, 2)-1))
+	m := c.v - chroma
+
+	var r, g, b float64
+	switch {
+	case c.h < 60:
+		r, g, b = chroma, x, 0
+	case c.h < 120:
+		r, g, b = x, chroma, 0
+	case c.h < 180:
+		r, g, b = 0, chroma, x
+	case c.h < 240:
+		r, g, b = 0, x, chroma
+	case c.h < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	to8 := func(f float64) uint8 {
+		return uint8(math.Round((f + m) * 255))
+	}
+	return rgb{to8(r), to8(g), to8(b)}
+}
+
+// visionSwatch renders a swatch of hex and, when a color vision mode is
+// simulated, a second swatch of how it appears under that mode.
+func (m Model) visionSwatch(hex string, width int) string {
+	if m.vision == visionNormal {
+		return swatch(hex, width)
+	}
+	return swatch(hex, width) + dimStyle.Render("→") + swatch(simulateHex(hex, m.vision), width)
+}
+
+// swatch renders a block of the given color. lipgloss emits truecolor when
+// the terminal supports it and the nearest palette color otherwise.
+func swatch(hex string, width int) string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(hex)).Render(strings.Repeat("█", width))
+}
+
+// colorOptions returns the entries of the color selection list: presets,
+// recently used colors that are not presets, the picker and custom entry.
+// While a color vision mode is simulated, the colorblind-safe palette
+// replaces the regular presets.
+func (m Model) colorOptions() []colorOption {
+	presets := config.ColorPresets
+	if m.vision != visionNormal {
+		presets = safePresets
+	}
+
+	options := make([]colorOption, 0, len(presets)+len(m.recent)+2)
+	seen := make(map[string]bool)
+
+	for _, preset := range presets {
+		options = append(options, colorOption{name: preset.Name, value: preset.Value, kind: optionPreset})
+		seen[strings.ToUpper(preset.Value)] = true
+	}
+
+	for _, c := range m.recent {
+		if seen[strings.ToUpper(c)] {
+			continue
+		}
+		seen[strings.ToUpper(c)] = true
+		options = append(options, colorOption{name: "Recent", value: c, kind: optionRecent})
+	}
+
+	options = append(options,
+		colorOption{name: "Pick with sliders...", kind: optionPicker},
+		colorOption{name: "Custom...", kind: optionCustom},
+	)
+	return options
+}
+
+// selectedColorOption returns the highlighted entry of the color list.
+func (m Model) selectedColorOption() colorOption {
+	options := m.colorOptions()
+	return options[min(m.cursor, len(options)-1)]
+}
+
+// colorOptionIndex returns the list index of color, or the index of the
+// custom entry when it is neither a preset nor a recent color.
+func (m Model) colorOptionIndex(color string) int {
+	options := m.colorOptions()
+	for i, opt := range options {
+		if opt.value != "" && strings.EqualFold(opt.value, color) {
+			return i
+		}
+	}
+	return len(options) - 1
+}
+
+// colorPicker holds the state of the HSV slider picker.
+type colorPicker struct {
+	color  hsv
+	slider int
+}
+
+// Picker sliders.
+const (
+	sliderHue = iota
+	sliderSaturation
+	sliderValue
+	sliderCount
+)
+
+var sliderNames = [sliderCount]string{"Hue", "Saturation", "Value"}
+
+// newColorPicker starts the picker at the given color.
+func newColorPicker(hex string) colorPicker {
+	c, ok := parseRGB(hex)
+	if !ok {
+		c = rgb{0xFF, 0xFF, 0xFF}
+	}
+	return colorPicker{color: c.toHSV()}
+}
+
+// hex returns the picked color as #RRGGBB.
+func (p colorPicker) hex() string {
+	return p.color.toRGB().hex()
+}
+
+// adjust moves the focused slider by steps. One step is 1° of hue or 1% of
+// saturation or value; hue wraps around.
+func (p colorPicker) adjust(steps int) colorPicker {
+	switch p.slider {
+	case sliderHue:
+		p.color.h = math.Mod(p.color.h+float64(steps)+360, 360)
+	case sliderSaturation:
+		p.color.s = math.Min(math.Max(p.color.s+float64(steps)/100, 0), 1)
+	case sliderValue:
+		p.color.v = math.Min(math.Max(p.color.v+float64(steps)/100, 0), 1)
+	}
+	return p
+}
+
+// sliderPosition returns where the focused value sits on a slider, in [0, 1].
+func (p colorPicker) sliderPosition(slider int) float64 {
+	switch slider {
+	case sliderHue:
+		return p.color.h / 360
+	case sliderSaturation:
+		return p.color.s
+	default:
+		return p.color.v
+	}
+}
+
+// sliderColor returns the color shown at position t of a slider, with the
+// other two components held at their current values.
+func (p colorPicker) sliderColor(slider int, t float64) hsv {
+	c := p.color
+	switch slider {
+	case sliderHue:
+		c.h = math.Min(t*360, 359.9)
+		c.s, c.v = 1, 1
+	case sliderSaturation:
+		c.s = t
+	case sliderValue:
+		c.v = t
+	}
+	return c
+}
+
+// sliderReadout formats the slider's value for display.
+func (p colorPicker) sliderReadout(slider int) string {
+	switch slider {
+	case sliderHue:
+		return fmt.Sprintf("%3.0f°", p.color.h)
+	case sliderSaturation:
+		return fmt.Sprintf("%3.0f%%", p.color.s*100)
+	default:
+		return fmt.Sprintf("%3.0f%%", p.color.v*100)
+	}
+}
+
+// renderSlider draws a gradient bar with a marker at the current value.
+func (p colorPicker) renderSlider(slider int) string {
+	marker := int(math.Round(p.sliderPosition(slider) * (sliderWidth - 1)))
+
+	var b strings.Builder
+	for i := range sliderWidth {
+		t := float64(i) / (sliderWidth - 1)
+		cell := p.sliderColor(slider, t).toRGB().hex()
+		if i == marker {
+			b.WriteString(lipgloss.NewStyle().
+				Foreground(lipgloss.Color(contrastingText(cell))).
+				Background(lipgloss.Color(cell)).
+				Render("●"))
+			continue
+		}
+		b.WriteString(swatch(cell, 1))
+	}
+	return b.String()
+}
+
+// renderColorPicker draws the HSV sliders and a swatch of the result.
+func (m Model) renderColorPicker() string {
+	var b strings.Builder
+	p := m.picker
+
+	b.WriteString(normalStyle.Render(field(m.step).Prompt) + "\n\n")
+
+	hex := p.hex()
+	b.WriteString("  " + m.visionSwatch(hex, 12) + "  " + selectedStyle.Render(hex) + "\n")
+	b.WriteString("  " + m.visionSwatch(hex, 12) + "\n\n")
+
+	for i := range sliderCount {
+		cursor := "  "
+		style := normalStyle
+		if i == p.slider {
+			cursor = "▸ "
+			style = selectedStyle
+		}
+		b.WriteString(cursor + style.Render(fmt.Sprintf("%-11s", sliderNames[i])) +
+			p.renderSlider(i) + " " + dimStyle.Render(p.sliderReadout(i)) + "\n")
+	}
+
+	return b.String()
+}
+
+// updatePicker handles keys while the HSV picker is open.
+func (m Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.picker.slider = max(m.picker.slider-1, 0)
+	case "down", "j":
+		m.picker.slider = min(m.picker.slider+1, sliderCount-1)
+	case "left", "h":
+		m.picker = m.picker.adjust(-5)
+	case "right", "l":
+		m.picker = m.picker.adjust(5)
+	case "shift+left", "H":
+		m.picker = m.picker.adjust(-1)
+	case "shift+right", "L":
+		m.picker = m.picker.adjust(1)
+	case "enter":
+		m.picking = false
+		if err := field(m.step).Set(m.config, m.picker.hex()); err != nil {
+			m.err = err
+			return m, nil
+		}
+		return m.advance(m.nextStep())
+	case "esc":
+		m.picking = false
+	}
+	return m, nil
+}
+
+// contrastingText returns black or white, whichever reads better on hex.
+func contrastingText(hex string) string {
+	c, _ := parseRGB(hex)
+	if 0.299*float64(c.r)+0.587*float64(c.g)+0.114*float64(c.b) > 140 {
+		return "#000000"
+	}
+	return "#FFFFFF"
+}
+
+// recentColorsPath returns the file that stores recently used colors, kept
+// next to the configuration file.
+func recentColorsPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "recent_colors")
+}
+
+// loadRecentColors reads the recently used colors, most recent first.
+// A missing or unreadable file yields an empty list.
+func loadRecentColors(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var colors []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && len(colors) < maxRecentColors {
+		if c, ok := parseRGB(scanner.Text()); ok {
+			colors = append(colors, c.hex())
+		}
+	}
+	return colors
+}
+
+// saveRecentColors writes the recently used colors, one per line.
+func saveRecentColors(path string, colors []string) error {
+	return os.WriteFile(path, []byte(strings.Join(colors, "\n")+"\n"), 0644)
+}
+
+// addRecentColors moves colors to the front of recent, dropping duplicates
+// and keeping at most maxRecentColors entries.
+func addRecentColors(recent []string, colors ...string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, c := range append(colors, recent...) {
+		parsed, ok := parseRGB(c)
+		if !ok || seen[parsed.hex()] {
+			continue
+		}
+		seen[parsed.hex()] = true
+		out = append(out, parsed.hex())
+	}
+	return out[:min(len(out), maxRecentColors)]
+}
@@ -0,0 +1,227 @@
+package wizard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"gocrosshair/config"
+)
+
+// formSaveKey identifies the action row at the bottom of the form.
+const formSaveKey = "save"
+
+// Form row layout, in terminal columns. The arrows are clickable.
+const (
+	formLabelWidth = 15
+	formValueWidth = 20
+	formLeftArrowX = 2 + formLabelWidth
+	formRightArrow = formLeftArrowX + 2 + formValueWidth + 1
+)
+
+// formRows returns the keys of the rows shown in the form: every field that
+// applies to the current configuration, followed by the save action.
+func (m Model) formRows() []string {
+	var rows []string
+	for _, f := range wizardFields() {
+		if fieldApplies(m.config, f.Key) {
+			rows = append(rows, f.Key)
+		}
+	}
+	return append(rows, formSaveKey)
+}
+
+// formIndex returns the row index of the focused field.
+func (m Model) formIndex() int {
+	return max(slices.Index(m.formRows(), m.formFocus), 0)
+}
+
+// enterForm switches to the form view with the field of the current step focused.
+func (m Model) enterForm() (Model, tea.Cmd) {
+	if key, ok := stepFields[m.step]; ok {
+		m.formFocus = key
+	}
+	m.formMode = true
+	m.jumped = false
+	m, cmd := m.enterStep(stepForm)
+	return m, tea.Batch(cmd, formScreen(true))
+}
+
+// leaveForm returns to the step-by-step view at the focused field.
+func (m Model) leaveForm() (Model, tea.Cmd) {
+	m.formMode = false
+	next := stepConfirm
+	for s, key := range stepFields {
+		if key == m.formFocus {
+			next = s
+		}
+	}
+	m, cmd := m.enterStep(next)
+	return m, tea.Batch(cmd, formScreen(false))
+}
+
+// formScreen switches the terminal for the form view. The form runs in the
+// alternate screen with mouse reporting, so click positions map directly to
+// rows; the step view stays inline and leaves the mouse to the terminal.
+func formScreen(on bool) tea.Cmd {
+	if on {
+		return tea.Sequence(tea.EnterAltScreen, tea.EnableMouseCellMotion)
+	}
+	return tea.Sequence(tea.ExitAltScreen, tea.DisableMouse)
+}
+
+// updateForm handles keys and mouse clicks in the form view.
+func (m Model) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	rows := m.formRows()
+	index := m.formIndex()
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "tab", "down", "j":
+			m.formFocus = rows[(index+1)%len(rows)]
+		case "shift+tab", "up", "k":
+			m.formFocus = rows[(index+len(rows)-1)%len(rows)]
+		case "left", "h", "-":
+			m.adjustFormField(-1)
+		case "right", "l", "+", "=":
+			m.adjustFormField(1)
+		case "shift+left":
+			m.adjustFormField(-10)
+		case "shift+right":
+			m.adjustFormField(10)
+		case "enter":
+			return m.editFormField()
+		}
+
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+			return m, nil
+		}
+		key, ok := m.formRowAt(msg.Y)
+		if !ok {
+			return m, nil
+		}
+		m.formFocus = key
+		switch {
+		case m.formFocus == formSaveKey:
+			return m.editFormField()
+		case msg.X == formLeftArrowX:
+			m.adjustFormField(-1)
+		case msg.X == formRightArrow:
+			m.adjustFormField(1)
+		}
+	}
+
+	return m, nil
+}
+
+// editFormField opens the detailed step for the focused field, or the
+// review screen for the save action. Both return to the form afterwards.
+func (m Model) editFormField() (tea.Model, tea.Cmd) {
+	if m.formFocus == formSaveKey {
+		return m.enterStep(stepConfirm)
+	}
+	for s, key := range stepFields {
+		if key == m.formFocus {
+			return m.enterStep(s)
+		}
+	}
+	return m, nil
+}
+
+// adjustFormField changes the focused field by delta: numbers are stepped
+// and clamped to their range, shapes cycle, colors cycle through the color
+// list, and the monitor cycles through the detected monitors.
+func (m *Model) adjustFormField(delta int) {
+	f, ok := config.LookupField(m.formFocus)
+	if !ok {
+		return
+	}
+
+	switch {
+	case f.Key == "monitor":
+		// -1 is the primary monitor, then each detected monitor in order.
+		count := len(m.monitors) + 1
+		pos := (f.Int(m.config) + 1 + delta%count + count) % count
+		_ = f.Set(m.config, fmt.Sprint(pos-1))
+
+	case f.Kind == config.KindInt:
+		val := min(max(f.Int(m.config)+delta, f.Min), f.Max)
+		_ = f.Set(m.config, fmt.Sprint(val))
+
+	case f.Kind == config.KindChoice:
+		i := slices.Index(f.Choices, f.Value(m.config))
+		n := len(f.Choices)
+		_ = f.Set(m.config, f.Choices[((i+delta)%n+n)%n])
+
+	case f.Kind == config.KindColor:
+		var colors []string
+		for _, opt := range m.colorOptions() {
+			if opt.value != "" {
+				colors = append(colors, opt.value)
+			}
+		}
+		i := slices.IndexFunc(colors, func(c string) bool {
+			return strings.EqualFold(c, f.Value(m.config))
+		})
+		if i < 0 && delta < 0 {
+			i = 0
+		}
+		n := len(colors)
+		_ = f.Set(m.config, colors[((i+delta)%n+n)%n])
+	}
+}
+
+// formRowAt returns the row key at screen line y, for mouse hit-testing.
+// It must match the layout produced by View and renderForm: the rows start
+// below the header and the form title, and a blank line precedes the save
+// action.
+func (m Model) formRowAt(y int) (string, bool) {
+	rows := m.formRows()
+	line := y - strings.Count(m.header(), "\n") - 2
+
+	switch {
+	case line >= 0 && line < len(rows)-1:
+		return rows[line], true
+	case line == len(rows):
+		return formSaveKey, true
+	default:
+		return "", false
+	}
+}
+
+// renderForm shows every applicable field on one screen.
+func (m Model) renderForm() string {
+	var b strings.Builder
+	b.WriteString(normalStyle.Render("All settings:") + "\n\n")
+
+	for _, key := range m.formRows() {
+		cursor := "  "
+		style := normalStyle
+		if key == m.formFocus {
+			cursor = "▸ "
+			style = selectedStyle
+		}
+
+		if key == formSaveKey {
+			b.WriteString("\n" + cursor + style.Render("[ Review & save ]") + "\n")
+			continue
+		}
+
+		f, _ := config.LookupField(key)
+		value := displayValue(f, m.config)
+		if f.Kind == config.KindColor {
+			value = m.visionSwatch(f.Value(m.config), 2) + " " + value
+		}
+		value = lipgloss.NewStyle().Width(formValueWidth).Render(value)
+
+		b.WriteString(cursor + style.Render(fmt.Sprintf("%-*s", formLabelWidth, f.Label)) +
+			dimStyle.Render("◂ ") + value + dimStyle.Render(" ▸") + "\n")
+	}
+
+	return b.String()
+}
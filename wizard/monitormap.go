@@ -0,0 +1,296 @@
+package wizard
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Monitor map size, in terminal cells. Cells are about twice as tall as they
+// are wide, so heights are halved to keep the rectangles proportional.
+const (
+	monitorMapWidth     = 48
+	monitorMapMaxHeight = 16
+)
+
+// Cell kinds in the monitor map, each drawn with its own style.
+const (
+	mapCellEmpty = iota
+	mapCellBorder
+	mapCellSelected
+	mapCellCrosshair
+)
+
+// mapCell is one character of the monitor map.
+type mapCell struct {
+	r    rune
+	kind int
+}
+
+// mapRect is a monitor's rectangle in map cells, inclusive.
+type mapRect struct {
+	x0, y0, x1, y1 int
+}
+
+// selectedMonitor returns the index into m.monitors of the monitor the
+// cursor refers to, resolving "Primary (auto-detect)" to the primary monitor.
+func (m Model) selectedMonitor() int {
+	if m.cursor > 0 {
+		return m.cursor - 1
+	}
+	for i, mon := range m.monitors {
+		if mon.Primary {
+			return i
+		}
+	}
+	return 0
+}
+
+// monitorBounds returns the desktop area covered by all monitors, in pixels.
+func (m Model) monitorBounds() (minX, minY, maxX, maxY int) {
+	minX, minY = math.MaxInt, math.MaxInt
+	maxX, maxY = math.MinInt, math.MinInt
+	for _, mon := range m.monitors {
+		minX = min(minX, int(mon.X))
+		minY = min(minY, int(mon.Y))
+		maxX = max(maxX, int(mon.X)+int(mon.Width))
+		maxY = max(maxY, int(mon.Y)+int(mon.Height))
+	}
+	return minX, minY, maxX, maxY
+}
+
+// renderMonitorMap draws the monitors as proportional rectangles, laid out
+// as they are on the desktop. The selected monitor is highlighted and a "+"
+// marks where the crosshair will be drawn, including its offset.
+func (m Model) renderMonitorMap() string {
+	if len(m.monitors) == 0 {
+		return ""
+	}
+
+	minX, minY, maxX, maxY := m.monitorBounds()
+	scaleX := float64(monitorMapWidth) / float64(max(maxX-minX, 1))
+	scaleY := scaleX / 2
+	height := int(math.Round(float64(maxY-minY) * scaleY))
+	if height > monitorMapMaxHeight {
+		scaleY = float64(monitorMapMaxHeight) / float64(max(maxY-minY, 1))
+		height = monitorMapMaxHeight
+	}
+
+	toCol := func(x int) int { return int(math.Round(float64(x-minX) * scaleX)) }
+	toRow := func(y int) int { return int(math.Round(float64(y-minY) * scaleY)) }
+
+	grid := make([][]mapCell, height)
+	for y := range grid {
+		grid[y] = make([]mapCell, monitorMapWidth)
+		for x := range grid[y] {
+			grid[y][x] = mapCell{' ', mapCellEmpty}
+		}
+	}
+	set := func(x, y int, r rune, kind int) {
+		if y >= 0 && y < len(grid) && x >= 0 && x < len(grid[y]) {
+			grid[y][x] = mapCell{r, kind}
+		}
+	}
+
+	selected := m.selectedMonitor()
+
+	// Draw the selected monitor last so its border wins where edges touch.
+	order := make([]int, 0, len(m.monitors))
+	for i := range m.monitors {
+		if i != selected {
+			order = append(order, i)
+		}
+	}
+	order = append(order, selected)
+
+	for _, i := range order {
+		mon := m.monitors[i]
+		rect := mapRect{
+			x0: toCol(int(mon.X)),
+			y0: toRow(int(mon.Y)),
+			x1: max(toCol(int(mon.X)+int(mon.Width))-1, toCol(int(mon.X))+2),
+			y1: max(toRow(int(mon.Y)+int(mon.Height))-1, toRow(int(mon.Y))+2),
+		}
+
+		kind, edges := mapCellBorder, "┌┐└┘─│"
+		if i == selected {
+			kind, edges = mapCellSelected, "╔╗╚╝═║"
+		}
+		corners := []rune(edges)
+
+		for x := rect.x0 + 1; x < rect.x1; x++ {
+			set(x, rect.y0, corners[4], kind)
+			set(x, rect.y1, corners[4], kind)
+		}
+		for y := rect.y0 + 1; y < rect.y1; y++ {
+			set(rect.x0, y, corners[5], kind)
+			set(rect.x1, y, corners[5], kind)
+			for x := rect.x0 + 1; x < rect.x1; x++ {
+				set(x, y, ' ', kind)
+			}
+		}
+		set(rect.x0, rect.y0, corners[0], kind)
+		set(rect.x1, rect.y0, corners[1], kind)
+		set(rect.x0, rect.y1, corners[2], kind)
+		set(rect.x1, rect.y1, corners[3], kind)
+
+		inner := rect.x1 - rect.x0 - 1
+		labels := []string{fmt.Sprintf("[%d] %s", mon.Index, mon.Name), fmt.Sprintf("%dx%d", mon.Width, mon.Height)}
+		for line, label := range labels {
+			y := rect.y0 + 1 + line
+			if y >= rect.y1 {
+				break
+			}
+			for j, r := range []rune(truncate(label, inner)) {
+				set(rect.x0+1+j, y, r, kind)
+			}
+		}
+	}
+
+	mon := m.monitors[selected]
+	cfg := m.previewConfig()
+	cx := int(mon.X) + int(mon.Width)/2 + cfg.Position.OffsetX
+	cy := int(mon.Y) + int(mon.Height)/2 + cfg.Position.OffsetY
+	set(min(max(toCol(cx), 0), monitorMapWidth-1), min(max(toRow(cy), 0), height-1), '+', mapCellCrosshair)
+
+	styles := map[int]lipgloss.Style{
+		mapCellEmpty:     normalStyle,
+		mapCellBorder:    dimStyle,
+		mapCellSelected:  selectedStyle,
+		mapCellCrosshair: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(cfg.Crosshair.Color)),
+	}
+
+	var b strings.Builder
+	for _, row := range grid {
+		b.WriteString("  ")
+		for start := 0; start < len(row); {
+			end := start
+			var run strings.Builder
+			for end < len(row) && row[end].kind == row[start].kind {
+				run.WriteRune(row[end].r)
+				end++
+			}
+			b.WriteString(styles[row[start].kind].Render(run.String()))
+			start = end
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// truncate shortens s to at most width runes, ending in "…" when cut.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 {
+		return ""
+	}
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// updateMonitorMap handles the arrow keys on the monitor step. They move
+// between monitors as they are laid out on the desktop. Up past the top
+// monitor selects "Primary (auto-detect)", and down from it selects the
+// primary monitor.
+func (m Model) updateMonitorMap(dx, dy int) Model {
+	if m.cursor == 0 && dy > 0 {
+		if len(m.monitors) > 0 {
+			m.cursor = m.selectedMonitor() + 1
+		}
+		return m
+	}
+	if moved, ok := m.moveMonitor(dx, dy); ok {
+		return moved
+	}
+	if dy < 0 {
+		m.cursor = 0
+	}
+	return m
+}
+
+// moveMonitor moves the cursor to the nearest monitor in the direction
+// (dx, dy), measured between monitor centers. Monitors mostly in line with
+// the direction are preferred over ones that are diagonal to it.
+// Monitors sharing a center, such as mirrored outputs, have no direction
+// between them, so they are stepped through in list order instead: right and
+// down go to the next one, left and up to the previous one.
+// It reports false when there is no monitor that way.
+func (m Model) moveMonitor(dx, dy int) (Model, bool) {
+	if len(m.monitors) == 0 {
+		return m, false
+	}
+
+	current := m.selectedMonitor()
+	from := m.monitors[current]
+	fx, fy := monitorCenter(from)
+
+	best, bestScore := -1, math.MaxInt
+	for i, mon := range m.monitors {
+		cx, cy := monitorCenter(mon)
+		along := (cx-fx)*dx + (cy-fy)*dy
+		across := (cx-fx)*dy + (cy-fy)*dx
+		if along <= 0 {
+			continue
+		}
+		if score := along + 2*abs(across); score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	if best < 0 {
+		best = m.coincidentMonitor(current, dx+dy > 0)
+	}
+	if best < 0 {
+		return m, false
+	}
+	m.cursor = best + 1
+	return m, true
+}
+
+// coincidentMonitor returns the next (or, if forward is false, the previous)
+// monitor in list order whose center is the same as that of monitor i, or -1
+// if there is none.
+func (m Model) coincidentMonitor(i int, forward bool) int {
+	fx, fy := monitorCenter(m.monitors[i])
+	step := -1
+	if forward {
+		step = 1
+	}
+	for j := i + step; j >= 0 && j < len(m.monitors); j += step {
+		if cx, cy := monitorCenter(m.monitors[j]); cx == fx && cy == fy {
+			return j
+		}
+	}
+	return -1
+}
+
+// arrowDirection returns the unit direction of an arrow or vi movement key.
+func arrowDirection(key string) (dx, dy int, ok bool) {
+	switch key {
+	case "left", "h":
+		return -1, 0, true
+	case "right", "l":
+		return 1, 0, true
+	case "up", "k":
+		return 0, -1, true
+	case "down", "j":
+		return 0, 1, true
+	}
+	return 0, 0, false
+}
+
+// monitorCenter returns the center of mon in desktop pixels.
+func monitorCenter(mon Monitor) (int, int) {
+	return int(mon.X) + int(mon.Width)/2, int(mon.Y) + int(mon.Height)/2
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,308 @@
+package wizard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/sys/unix"
+
+	"gocrosshair/config"
+	"gocrosshair/render"
+)
+
+// previewMode selects how the crosshair preview is drawn in the terminal.
+type previewMode int
+
+const (
+	previewHalfBlock previewMode = iota
+	previewKitty
+	previewSixel
+	previewOff
+)
+
+// previewEnvVar overrides terminal graphics detection.
+// Accepted values: "halfblock", "kitty", "sixel", "off".
+const previewEnvVar = "GOCROSSHAIR_PREVIEW"
+
+// Preview size limits, in terminal cells.
+const (
+	previewMaxCols = 40
+	previewMaxRows = 20
+)
+
+// kittyImageID is the image ID reused for every preview frame so that each
+// redraw replaces the previous image instead of stacking new ones.
+const kittyImageID = 4242
+
+var previewBorderStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("241")).
+	Padding(0, 1)
+
+// detectPreviewMode picks the best preview the terminal is known to support.
+func detectPreviewMode() previewMode {
+	switch strings.ToLower(os.Getenv(previewEnvVar)) {
+	case "halfblock":
+		return previewHalfBlock
+	case "kitty":
+		return previewKitty
+	case "sixel":
+		return previewSixel
+	case "off", "none":
+		return previewOff
+	}
+
+	term := os.Getenv("TERM")
+	termProgram := os.Getenv("TERM_PROGRAM")
+
+	if os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" ||
+		termProgram == "WezTerm" || termProgram == "ghostty" {
+		return previewKitty
+	}
+
+	if strings.Contains(term, "sixel") || term == "foot" || strings.HasPrefix(term, "mlterm") {
+		return previewSixel
+	}
+
+	return previewHalfBlock
+}
+
+// renderPreview draws the crosshair described by cfg as a framed terminal panel.
+func renderPreview(cfg *config.Config, mode previewMode) string {
+	if mode == previewOff {
+		return ""
+	}
+
+	img, cropped := previewImage(cfg)
+
+	var body string
+	switch mode {
+	case previewKitty:
+		body = kittyPreview(img)
+	case previewSixel:
+		body = sixelPreview(img)
+	default:
+		body = halfBlockPreview(img)
+	}
+
+	title := dimStyle.Render("Preview")
+	if cropped {
+		title += dimStyle.Render(" (cropped)")
+	}
+
+	return previewBorderStyle.Render(title + "\n" + body)
+}
+
+// previewImage rasterizes cfg at 1:1 and crops it around the center so it
+// fits in the preview panel. Half-block cells hold two pixels vertically.
+func previewImage(cfg *config.Config) (*image.RGBA, bool) {
+	img := render.Rasterize(cfg, 1)
+	b := img.Bounds()
+
+	maxW := previewMaxCols
+	maxH := previewMaxRows * 2
+	if b.Dx() <= maxW && b.Dy() <= maxH {
+		return img, false
+	}
+
+	w := min(b.Dx(), maxW)
+	h := min(b.Dy(), maxH)
+	x0 := (b.Dx() - w) / 2
+	y0 := (b.Dy() - h) / 2
+	return img.SubImage(image.Rect(x0, y0, x0+w, y0+h)).(*image.RGBA), true
+}
+
+// halfBlockPreview renders two pixel rows per text row using "▀" and "▄"
+// with foreground and background colors. lipgloss emits truecolor when the
+// terminal supports it and degrades to the nearest palette color otherwise.
+func halfBlockPreview(img *image.RGBA) string {
+	b := img.Bounds()
+	var out strings.Builder
+
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top := img.RGBAAt(x, y)
+			bottom := color.RGBA{}
+			if y+1 < b.Max.Y {
+				bottom = img.RGBAAt(x, y+1)
+			}
+
+			switch {
+			case top.A == 0 && bottom.A == 0:
+				out.WriteString(" ")
+			case bottom.A == 0:
+				out.WriteString(lipgloss.NewStyle().Foreground(hexOf(top)).Render("▀"))
+			case top.A == 0:
+				out.WriteString(lipgloss.NewStyle().Foreground(hexOf(bottom)).Render("▄"))
+			default:
+				out.WriteString(lipgloss.NewStyle().Foreground(hexOf(top)).Background(hexOf(bottom)).Render("▀"))
+			}
+		}
+		if y+2 < b.Max.Y {
+			out.WriteString("\n")
+		}
+	}
+
+	return out.String()
+}
+
+// kittyPreview transmits the image with the Kitty graphics protocol and
+// reserves the cells it covers with blank lines. The cursor is not moved by
+// the image, so the surrounding layout stays intact.
+func kittyPreview(img *image.RGBA) string {
+	cols, rows := previewCells(img)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return halfBlockPreview(img)
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	var out strings.Builder
+	// Delete the previous frame before placing the new one.
+	fmt.Fprintf(&out, "\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+
+	const chunkSize = 4096
+	for i := 0; i < len(payload); i += chunkSize {
+		end := min(i+chunkSize, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&out, "\x1b_Ga=T,f=100,i=%d,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\",
+				kittyImageID, cols, rows, more, payload[i:end])
+		} else {
+			fmt.Fprintf(&out, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+
+	out.WriteString(blankCells(cols, rows))
+	return out.String()
+}
+
+// sixelPreview encodes the image as a Sixel graphic scaled so that each
+// crosshair pixel covers roughly half a cell, matching the half-block view.
+// Without a known cell size it falls back to half-block rendering.
+func sixelPreview(img *image.RGBA) string {
+	cellW, cellH := terminalCellSize()
+	if cellW == 0 || cellH == 0 {
+		return halfBlockPreview(img)
+	}
+
+	scaleX := max(cellW, 1)
+	scaleY := max(cellH/2, 1)
+	cols, rows := previewCells(img)
+
+	return encodeSixel(img, scaleX, scaleY) + "\n" + blankCells(cols, rows-1)
+}
+
+// encodeSixel converts an image with a handful of colors into a Sixel
+// sequence. Transparent pixels are left unpainted.
+func encodeSixel(img *image.RGBA, scaleX, scaleY int) string {
+	b := img.Bounds()
+	width := b.Dx() * scaleX
+	height := b.Dy() * scaleY
+
+	at := func(x, y int) color.RGBA {
+		return img.RGBAAt(b.Min.X+x/scaleX, b.Min.Y+y/scaleY)
+	}
+
+	var palette []color.RGBA
+	index := make(map[color.RGBA]int)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			if c.A == 0 {
+				continue
+			}
+			if _, ok := index[c]; !ok {
+				index[c] = len(palette)
+				palette = append(palette, c)
+			}
+		}
+	}
+
+	var out strings.Builder
+	// P2=1 keeps unpainted pixels transparent.
+	out.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&out, "\"1;1;%d;%d", width, height)
+	for i, c := range palette {
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, int(c.R)*100/255, int(c.G)*100/255, int(c.B)*100/255)
+	}
+
+	for band := 0; band < height; band += 6 {
+		for i, c := range palette {
+			fmt.Fprintf(&out, "#%d", i)
+			var last byte
+			run := 0
+			flush := func() {
+				switch {
+				case run == 0:
+				case run > 3:
+					fmt.Fprintf(&out, "!%d%c", run, last)
+				default:
+					out.WriteString(strings.Repeat(string(last), run))
+				}
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < height; dy++ {
+					if at(x, band+dy) == c {
+						bits |= 1 << dy
+					}
+				}
+				ch := bits + 63
+				if ch == last && run > 0 {
+					run++
+					continue
+				}
+				flush()
+				last, run = ch, 1
+			}
+			flush()
+			out.WriteString("$")
+		}
+		out.WriteString("-")
+	}
+
+	out.WriteString("\x1b\\")
+	return out.String()
+}
+
+// previewCells returns the number of terminal cells the preview occupies.
+func previewCells(img *image.RGBA) (cols, rows int) {
+	b := img.Bounds()
+	return b.Dx(), (b.Dy() + 1) / 2
+}
+
+// blankCells returns rows lines of cols spaces.
+func blankCells(cols, rows int) string {
+	if rows <= 0 {
+		return ""
+	}
+	line := strings.Repeat(" ", cols)
+	return strings.TrimSuffix(strings.Repeat(line+"\n", rows), "\n")
+}
+
+// terminalCellSize returns the pixel size of a terminal cell, or zeros when
+// the terminal does not report its pixel dimensions.
+func terminalCellSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 0, 0
+	}
+	return int(ws.Xpixel) / int(ws.Col), int(ws.Ypixel) / int(ws.Row)
+}
+
+// hexOf converts a pixel color into a lipgloss color.
+func hexOf(c color.RGBA) lipgloss.Color {
+	return lipgloss.Color(fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B))
+}
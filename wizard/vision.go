@@ -0,0 +1,167 @@
+package wizard
+
+import (
+	"fmt"
+	"math"
+
+	"gocrosshair/config"
+)
+
+// visionMode is a simulated type of color vision.
+type visionMode int
+
+const (
+	visionNormal visionMode = iota
+	visionProtanopia
+	visionDeuteranopia
+	visionTritanopia
+	visionModeCount
+)
+
+var visionNames = [visionModeCount]string{"Normal", "Protanopia", "Deuteranopia", "Tritanopia"}
+
+// String returns the display name of the mode.
+func (v visionMode) String() string {
+	return visionNames[v]
+}
+
+// next returns the mode that follows v, wrapping around.
+func (v visionMode) next() visionMode {
+	return (v + 1) % visionModeCount
+}
+
+// minContrastRatio is the WCAG 2.1 minimum contrast for graphical objects.
+const minContrastRatio = 3.0
+
+// visionMatrices are the full-severity dichromacy simulation matrices from
+// Machado, Oliveira and Fernandes (2009), applied to linear RGB.
+var visionMatrices = [visionModeCount][3][3]float64{
+	visionProtanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	visionDeuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	visionTritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// safePresets is a palette that stays distinguishable under all three
+// dichromacies, based on the Okabe-Ito palette plus white and black.
+var safePresets = []config.ColorPreset{
+	{Name: "White", Value: "#FFFFFF"},
+	{Name: "Yellow", Value: "#F0E442"},
+	{Name: "Orange", Value: "#E69F00"},
+	{Name: "Sky blue", Value: "#56B4E9"},
+	{Name: "Blue", Value: "#0072B2"},
+	{Name: "Vermillion", Value: "#D55E00"},
+	{Name: "Reddish purple", Value: "#CC79A7"},
+	{Name: "Black", Value: "#000000"},
+}
+
+// linearize converts an sRGB channel to linear light.
+func linearize(c uint8) float64 {
+	f := float64(c) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+// delinearize converts linear light back to an sRGB channel.
+func delinearize(f float64) uint8 {
+	f = math.Min(math.Max(f, 0), 1)
+	if f <= 0.0031308 {
+		f *= 12.92
+	} else {
+		f = 1.055*math.Pow(f, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(f * 255))
+}
+
+// simulate returns how c appears to someone with the given color vision.
+func (c rgb) simulate(mode visionMode) rgb {
+	if mode == visionNormal {
+		return c
+	}
+
+	m := visionMatrices[mode]
+	lin := [3]float64{linearize(c.r), linearize(c.g), linearize(c.b)}
+
+	var out [3]float64
+	for i := range out {
+		out[i] = m[i][0]*lin[0] + m[i][1]*lin[1] + m[i][2]*lin[2]
+	}
+
+	return rgb{delinearize(out[0]), delinearize(out[1]), delinearize(out[2])}
+}
+
+// luminance returns the WCAG relative luminance of c.
+func (c rgb) luminance() float64 {
+	return 0.2126*linearize(c.r) + 0.7152*linearize(c.g) + 0.0722*linearize(c.b)
+}
+
+// contrastRatio returns the WCAG contrast ratio between two colors, from 1 to 21.
+func contrastRatio(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// simulateHex applies the vision mode to a hex color, returning it unchanged
+// if it cannot be parsed.
+func simulateHex(hex string, mode visionMode) string {
+	c, ok := parseRGB(hex)
+	if !ok {
+		return hex
+	}
+	return c.simulate(mode).hex()
+}
+
+// simulatedConfig returns a copy of cfg with both colors as they appear
+// under the given vision mode. It is used for the terminal preview only.
+func simulatedConfig(cfg *config.Config, mode visionMode) *config.Config {
+	if mode == visionNormal {
+		return cfg
+	}
+	sim := *cfg
+	sim.Crosshair.Color = simulateHex(cfg.Crosshair.Color, mode)
+	sim.Crosshair.OutlineColor = simulateHex(cfg.Crosshair.OutlineColor, mode)
+	return &sim
+}
+
+// contrastWarning returns a warning when the crosshair and outline colors in
+// cfg are too close to tell apart under the given vision mode, or "" if the
+// contrast is sufficient or there is no outline.
+func contrastWarning(cfg *config.Config, mode visionMode) string {
+	if cfg.Crosshair.OutlineThickness <= 0 {
+		return ""
+	}
+
+	color, ok1 := parseRGB(cfg.Crosshair.Color)
+	outline, ok2 := parseRGB(cfg.Crosshair.OutlineColor)
+	if !ok1 || !ok2 {
+		return ""
+	}
+
+	ratio := contrastRatio(color.simulate(mode), outline.simulate(mode))
+	if ratio >= minContrastRatio {
+		return ""
+	}
+
+	seen := ""
+	if mode != visionNormal {
+		seen = fmt.Sprintf(" with %s", mode)
+	}
+	return fmt.Sprintf("⚠ Crosshair/outline contrast is %.1f:1%s (at least %.0f:1 recommended)",
+		ratio, seen, minContrastRatio)
+}
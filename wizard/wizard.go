@@ -3,7 +3,9 @@ package wizard
 
 import (
 	"fmt"
-	"strconv"
+	"os"
+	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -11,6 +13,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"gocrosshair/config"
+	"gocrosshair/overlay"
 )
 
 var (
@@ -36,6 +39,9 @@ var (
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196"))
 
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
 			MarginTop(1)
@@ -54,14 +60,22 @@ const (
 	stepMonitor
 	stepOffsetX
 	stepOffsetY
+	stepAnchor
+	stepWindowClass
+	stepWindowTitle
+	stepVisibility
 	stepConfirm
 	stepStartPrompt
 	stepDone
+	stepJump
+	stepForm
 )
 
 type Monitor struct {
 	Index   int
 	Name    string
+	X       int16
+	Y       int16
 	Width   uint16
 	Height  uint16
 	Primary bool
@@ -71,48 +85,189 @@ type Monitor struct {
 type Model struct {
 	step           step
 	config         *config.Config
+	original       *config.Config
+	jumped         bool
+	formMode       bool
+	formFocus      string
+	recent         []string
+	picker         colorPicker
+	picking        bool
+	vision         visionMode
 	monitors       []Monitor
 	configPath     string
+	loadErr        error // The existing file could not be parsed
+	loadedInvalid  bool  // The existing file parsed but failed validation
 	cursor         int
 	textInput      textinput.Model
 	err            error
 	quitting       bool
 	saved          bool
 	startCrosshair bool
+	previewMode    previewMode
+	live           *livePreview
 	width, height  int
 }
 
-var shapeOptions = []string{"cross", "dot", "circle", "cross-dot"}
+// livePreview tracks the on-screen overlay driven by the wizard. It is shared
+// by pointer so every copy of the Model sees what was last applied.
+type livePreview struct {
+	overlay   *overlay.Overlay
+	crosshair config.CrosshairConfig
+	position  config.PositionConfig
+	applied   bool
+}
 
-var colorPresets = []struct {
-	name  string
-	value string
-}{
-	{"Green", "#00FF00"},
-	{"Red", "#FF0000"},
-	{"White", "#FFFFFF"},
-	{"Cyan", "#00FFFF"},
-	{"Yellow", "#FFFF00"},
-	{"Pink", "#FF00FF"},
-	{"Orange", "#FFA500"},
-	{"Custom...", "custom"},
-}
-
-// NewModel creates a new wizard model
+// Confirm screen options.
+const (
+	confirmSave      = "Save configuration"
+	confirmEdit      = "Edit a field..."
+	confirmStartOver = "Start over"
+	confirmCancel    = "Cancel"
+)
+
+var confirmOptions = []string{confirmSave, confirmEdit, confirmStartOver, confirmCancel}
+
+// stepFields maps each field step to the schema key it edits.
+var stepFields = map[step]string{
+	stepShape:        "shape",
+	stepColor:        "color",
+	stepSize:         "size",
+	stepThickness:    "thickness",
+	stepGap:          "gap",
+	stepOutline:      "outline_thickness",
+	stepOutlineColor: "outline_color",
+	stepMonitor:      "monitor",
+	stepOffsetX:      "offset_x",
+	stepOffsetY:      "offset_y",
+	stepAnchor:       "anchor",
+	stepWindowClass:  "window_class",
+	stepWindowTitle:  "window_title",
+	stepVisibility:   "visibility",
+}
+
+// startOverConfig returns the configuration the wizard restarts from: the
+// loaded file with every setting the wizard asks for put back to its
+// default. Profiles, keys and the other sections it never edits are kept.
+func (m Model) startOverConfig() *config.Config {
+	if m.original == nil {
+		return config.Default()
+	}
+	cfg := *m.original
+	defaults := config.Default()
+	for _, key := range stepFields {
+		f, _ := config.LookupField(key)
+		_ = f.Set(&cfg, f.Value(defaults))
+	}
+	return &cfg
+}
+
+// wizardFields returns the schema entries the wizard has a step for, in
+// schema order. The other settings are left to the config file and -set.
+func wizardFields() []config.Field {
+	var fields []config.Field
+	for _, f := range config.Fields {
+		for _, key := range stepFields {
+			if f.Name() == key {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// field returns the schema entry edited by step s.
+func field(s step) config.Field {
+	f, _ := config.LookupField(stepFields[s])
+	return f
+}
+
+// NewModel creates a new wizard model. If a configuration already exists at
+// configPath, every step starts from its values instead of the defaults,
+// and the confirm screen shows what changed. It is loaded even if it fails
+// validation, so the wizard can fix the bad fields without losing the rest;
+// only a file that cannot be parsed is started over from the defaults, with
+// a warning that saving replaces it.
 func NewModel(monitors []Monitor, configPath string) Model {
 	ti := textinput.New()
-	ti.Focus()
 	ti.CharLimit = 20
 	ti.Width = 20
 
-	return Model{
-		step:       stepShape,
-		config:     config.Default(),
-		monitors:   monitors,
-		configPath: configPath,
-		cursor:     0,
-		textInput:  ti,
+	m := Model{
+		config:      config.Default(),
+		monitors:    monitors,
+		configPath:  configPath,
+		textInput:   ti,
+		previewMode: detectPreviewMode(),
+		recent:      loadRecentColors(recentColorsPath(configPath)),
+	}
+
+	if _, err := os.Stat(configPath); err == nil {
+		if existing, err := config.Load(configPath); err != nil {
+			m.loadErr = err
+		} else {
+			original := *existing
+			m.config = existing
+			m.original = &original
+			m.loadedInvalid = existing.Validate() != nil
+		}
+	}
+
+	m, _ = m.enterStep(stepShape)
+	return m
+}
+
+// WithLivePreview attaches a running overlay that is redrawn whenever the
+// value being edited changes. The wizard closes it on cancel and leaves it
+// running when the user chooses to start the crosshair.
+func (m Model) WithLivePreview(o *overlay.Overlay) Model {
+	if o == nil {
+		return m
+	}
+	m.live = &livePreview{overlay: o}
+	m.syncLivePreview()
+	return m
+}
+
+// LivePreview returns the overlay attached with WithLivePreview, or nil if
+// there is none or it has already been closed.
+func (m Model) LivePreview() *overlay.Overlay {
+	if m.live == nil {
+		return nil
 	}
+	return m.live.overlay
+}
+
+// syncLivePreview pushes the in-progress configuration to the live overlay
+// when it differs from what is currently shown.
+func (m Model) syncLivePreview() {
+	if m.live == nil || m.live.overlay == nil {
+		return
+	}
+
+	if m.quitting && !m.startCrosshair {
+		m.live.overlay.Close()
+		m.live.overlay = nil
+		return
+	}
+
+	// The preview is drawn over the terminal, with the cursor showing, so it
+	// is shown whatever the visibility, desktop and cursor settings say.
+	cfg := m.previewConfig()
+	cfg.Position.Visibility = config.VisibilityAlways
+	cfg.Position.Desktops = nil
+	cfg.Cursor.AutoHide = false
+	cfg.Idle.HideAfter = 0
+	if m.live.applied && cfg.Crosshair == m.live.crosshair && reflect.DeepEqual(cfg.Position, m.live.position) {
+		return
+	}
+
+	// A failed redraw is retried on the next update.
+	if err := m.live.overlay.Apply(cfg); err != nil {
+		return
+	}
+	m.live.crosshair = cfg.Crosshair
+	m.live.position = cfg.Position
+	m.live.applied = true
 }
 
 // Init implements tea.Model
@@ -122,10 +277,56 @@ func (m Model) Init() tea.Cmd {
 
 // Update implements tea.Model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if m, ok := model.(Model); ok {
+		m.syncLivePreview()
+	}
+	return model, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// On text steps ctrl+v belongs to the input, where it pastes.
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "ctrl+v" && !m.isTextInputStep() {
+		m.vision = m.vision.next()
+		if m.isColorStep() && !m.picking {
+			m.cursor = min(m.cursor, m.maxCursor())
+		}
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && m.picking && msg.String() != "ctrl+c" {
+		return m.updatePicker(msg)
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "ctrl+f" && (m.step <= stepConfirm || m.step == stepForm) {
+		if m.formMode {
+			return m.leaveForm()
+		}
+		return m.enterForm()
+	}
+
+	if m.step == stepForm {
+		if msg, ok := msg.(tea.KeyMsg); ok && (msg.String() == "ctrl+c" || msg.String() == "q") {
+			m.quitting = true
+			return m, tea.Quit
+		}
+		return m.updateForm(msg)
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && m.step == stepMonitor {
+		if dx, dy, ok := arrowDirection(msg.String()); ok {
+			return m.updateMonitorMap(dx, dy), nil
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
+			// On text steps q is typed, as window classes may contain it.
+			if msg.String() == "q" && m.isTextInputStep() {
+				break
+			}
 			m.quitting = true
 			return m, tea.Quit
 
@@ -140,22 +341,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			return m.handleEnter()
 
+		case "ctrl+g":
+			if m.step <= stepConfirm && !m.formMode {
+				return m.openJumpMenu()
+			}
+
 		case "esc":
-			if m.step > stepShape {
-				m.step--
-				// Skip thickness and gap when going back for dot/circle shapes
-				if !m.shapeNeedsThicknessAndGap() {
-					if m.step == stepGap || m.step == stepThickness {
-						m.step = stepSize
-						m.textInput.SetValue(strconv.Itoa(m.config.Crosshair.Size))
-						m.textInput.Focus()
-						m.cursor = 0
-						m.err = nil
-						return m, textinput.Blink
-					}
-				}
-				m.cursor = 0
-				m.err = nil
+			if m.formMode && m.step <= stepConfirm {
+				return m.enterStep(stepForm)
+			}
+			if m.step == stepJump || m.jumped {
+				m.jumped = false
+				return m.enterStep(stepConfirm)
+			}
+			if m.step > stepShape && m.step <= stepConfirm {
+				return m.enterStep(m.previousStep())
 			}
 		}
 
@@ -188,57 +388,132 @@ func (m Model) View() string {
 
 	var b strings.Builder
 
-	b.WriteString(titleStyle.Render("🎯 gocrosshair Setup Wizard") + "\n\n")
-
 	switch m.step {
-	case stepShape:
-		b.WriteString(m.renderSelect("Select crosshair shape:", shapeOptions))
-
-	case stepColor:
-		b.WriteString(m.renderColorSelect())
-
-	case stepSize:
-		b.WriteString(m.renderNumberInput("Crosshair size (pixels from center):", "10", 1, 100))
-
-	case stepThickness:
-		b.WriteString(m.renderNumberInput("Line thickness (pixels):", "2", 1, 20))
+	case stepShape, stepAnchor, stepVisibility:
+		b.WriteString(m.renderSelect(field(m.step).Prompt, field(m.step).Choices))
 
-	case stepGap:
-		b.WriteString(m.renderNumberInput("Center gap (0 for solid, or pixels):", "0", 0, 50))
-
-	case stepOutline:
-		b.WriteString(m.renderSelect("Add outline?", []string{"No", "Yes"}))
+	case stepColor, stepOutlineColor:
+		if m.picking {
+			b.WriteString(m.renderColorPicker())
+		} else {
+			b.WriteString(m.renderColorSelect())
+		}
 
-	case stepOutlineColor:
-		b.WriteString(m.renderColorSelect())
+	case stepSize, stepThickness, stepGap, stepOutline, stepOffsetX, stepOffsetY, stepWindowClass, stepWindowTitle:
+		b.WriteString(m.renderTextInput(field(m.step)))
 
 	case stepMonitor:
 		b.WriteString(m.renderMonitorSelect())
 
-	case stepOffsetX:
-		b.WriteString(m.renderNumberInput("Horizontal offset from center (pixels, negative=left):", "0", -500, 500))
-
-	case stepOffsetY:
-		b.WriteString(m.renderNumberInput("Vertical offset from center (pixels, negative=up):", "0", -500, 500))
-
 	case stepConfirm:
 		b.WriteString(m.renderConfirm())
 
+	case stepJump:
+		b.WriteString(m.renderJumpMenu())
+
+	case stepForm:
+		b.WriteString(m.renderForm())
+
 	case stepStartPrompt:
 		b.WriteString(m.renderStartPrompt())
 
 	case stepDone:
 		b.WriteString(successStyle.Render("✓ Configuration saved!") + "\n")
-		return b.String()
+		return m.header() + b.String()
+	}
+
+	if m.isColorStep() || m.step == stepConfirm {
+		if warning := contrastWarning(m.previewConfig(), m.vision); warning != "" {
+			b.WriteString("\n" + warningStyle.Render(warning) + "\n")
+		}
 	}
 
 	if m.err != nil {
 		b.WriteString("\n" + errorStyle.Render("Error: "+m.err.Error()))
 	}
 
-	b.WriteString(helpStyle.Render("\n↑/↓ navigate • enter select • esc back • q quit"))
+	switch {
+	case m.picking:
+		b.WriteString(helpStyle.Render("\n↑/↓ slider • ←/→ adjust (shift for fine) • enter use color • esc back • q quit"))
+	case m.step == stepForm:
+		b.WriteString(helpStyle.Render("\ntab/↑↓ focus • ←/→ or +/- change • enter edit • ctrl+f step view • ctrl+v color vision • q quit"))
+	case m.step == stepMonitor && !m.formMode:
+		b.WriteString(helpStyle.Render("\n←/→/↑/↓ move between monitors • enter select • esc back • ctrl+g jump to field • ctrl+f form view • q quit"))
+	case m.formMode:
+		b.WriteString(helpStyle.Render("\n↑/↓ navigate • enter select • esc back to form • ctrl+f step view • " + m.quitHelp()))
+	default:
+		b.WriteString(helpStyle.Render("\n↑/↓ navigate • enter select • esc back • ctrl+g jump to field • ctrl+f form view • " + m.visionHelp() + m.quitHelp()))
+	}
+
+	return m.header() + m.withPreview(b.String())
+}
 
-	return b.String()
+// header returns the title and, while a color vision mode is simulated,
+// the notice about it.
+func (m Model) header() string {
+	header := titleStyle.Render("🎯 gocrosshair Setup Wizard") + "\n\n"
+	if m.vision != visionNormal {
+		header += warningStyle.Render("Simulating "+m.vision.String()+" color vision (ctrl+v to change)") + "\n\n"
+	}
+	if m.loadErr != nil {
+		header += warningStyle.Render(fmt.Sprintf("%s could not be read and will be replaced on save: %v", m.configPath, m.loadErr)) + "\n\n"
+	} else if m.loadedInvalid {
+		if err := m.config.Validate(); err != nil {
+			header += warningStyle.Render("The saved configuration needs fixing before it can be saved:\n  - "+err.Error()) + "\n\n"
+		}
+	}
+	return header
+}
+
+// withPreview places the crosshair preview next to the step content.
+// Sixel graphics move the cursor, so they are placed below everything else.
+func (m Model) withPreview(content string) string {
+	preview := renderPreview(simulatedConfig(m.previewConfig(), m.vision), m.previewMode)
+	switch {
+	case preview == "":
+		return content
+	case m.previewMode == previewSixel:
+		return content + "\n\n" + preview
+	default:
+		return lipgloss.JoinHorizontal(lipgloss.Top, content, "   ", preview)
+	}
+}
+
+// previewConfig returns the configuration with the value currently being
+// edited applied, so the preview follows the cursor and typed input.
+// Values that do not pass validation yet are ignored.
+func (m Model) previewConfig() *config.Config {
+	cfg := *m.config
+
+	switch m.step {
+	case stepShape, stepAnchor, stepVisibility:
+		_ = field(m.step).Set(&cfg, field(m.step).Choices[m.cursor])
+	case stepColor, stepOutlineColor:
+		_ = field(m.step).Set(&cfg, m.selectedColor())
+	case stepSize, stepThickness, stepGap, stepOutline, stepOffsetX, stepOffsetY, stepWindowClass, stepWindowTitle:
+		_ = field(m.step).Set(&cfg, m.textInput.Value())
+	case stepMonitor:
+		cfg.Position.Monitor = m.cursor - 1
+	}
+
+	return &cfg
+}
+
+// selectedColor returns the color currently chosen on a color step: the
+// picker's color, the typed custom color, or the highlighted entry.
+func (m Model) selectedColor() string {
+	if m.picking {
+		return m.picker.hex()
+	}
+	opt := m.selectedColorOption()
+	switch opt.kind {
+	case optionCustom:
+		return m.textInput.Value()
+	case optionPicker:
+		return field(m.step).Value(m.config)
+	default:
+		return opt.value
+	}
 }
 
 func (m Model) renderSelect(title string, options []string) string {
@@ -261,35 +536,33 @@ func (m Model) renderSelect(title string, options []string) string {
 func (m Model) renderColorSelect() string {
 	var b strings.Builder
 
-	if m.step == stepColor {
-		b.WriteString(normalStyle.Render("Select crosshair color:") + "\n\n")
-	} else {
-		b.WriteString(normalStyle.Render("Select outline color:") + "\n\n")
-	}
+	b.WriteString(normalStyle.Render(field(m.step).Prompt) + "\n\n")
 
-	// Check if we're in custom input mode
-	if m.cursor == len(colorPresets)-1 && m.textInput.Focused() {
-		for i, preset := range colorPresets[:len(colorPresets)-1] {
-			b.WriteString("  " + normalStyle.Render(preset.name) + dimStyle.Render(" "+preset.value) + "\n")
-			_ = i
-		}
-		b.WriteString("▸ " + selectedStyle.Render("Custom: ") + m.textInput.View() + "\n")
-		b.WriteString(dimStyle.Render("  (Enter hex color like #FF0000)") + "\n")
-		return b.String()
-	}
+	// Align entries without a swatch with the names of those that have one.
+	indent := strings.Repeat(" ", lipgloss.Width(m.visionSwatch("#000000", 2))+1)
 
-	for i, preset := range colorPresets {
+	options := m.colorOptions()
+	for i, opt := range options {
 		cursor := "  "
 		style := normalStyle
 		if i == m.cursor {
 			cursor = "▸ "
 			style = selectedStyle
 		}
-		colorPreview := ""
-		if preset.value != "custom" {
-			colorPreview = dimStyle.Render(" " + preset.value)
+
+		switch opt.kind {
+		case optionPreset, optionRecent:
+			b.WriteString(cursor + m.visionSwatch(opt.value, 2) + " " + style.Render(opt.name) + dimStyle.Render(" "+opt.value) + "\n")
+		case optionCustom:
+			if i == m.cursor && m.textInput.Focused() {
+				b.WriteString(cursor + indent + selectedStyle.Render("Custom: ") + m.textInput.View() + "\n")
+				b.WriteString(dimStyle.Render("  "+indent+"(Enter hex color like #FF0000)") + "\n")
+				continue
+			}
+			b.WriteString(cursor + indent + style.Render(opt.name) + "\n")
+		default:
+			b.WriteString(cursor + indent + style.Render(opt.name) + "\n")
 		}
-		b.WriteString(cursor + style.Render(preset.name) + colorPreview + "\n")
 	}
 
 	return b.String()
@@ -297,7 +570,7 @@ func (m Model) renderColorSelect() string {
 
 func (m Model) renderMonitorSelect() string {
 	var b strings.Builder
-	b.WriteString(normalStyle.Render("Select monitor:") + "\n\n")
+	b.WriteString(normalStyle.Render(field(stepMonitor).Prompt) + "\n\n")
 
 	// Add "Primary (auto)" option
 	cursor := "  "
@@ -323,45 +596,59 @@ func (m Model) renderMonitorSelect() string {
 		b.WriteString(cursor + style.Render(desc) + primary + "\n")
 	}
 
+	if monitorMap := m.renderMonitorMap(); monitorMap != "" {
+		b.WriteString("\n" + monitorMap)
+	}
+
 	return b.String()
 }
 
-func (m Model) renderNumberInput(title, defaultVal string, minVal, maxVal int) string {
+func (m Model) renderTextInput(f config.Field) string {
 	var b strings.Builder
-	b.WriteString(normalStyle.Render(title) + "\n\n")
+	b.WriteString(normalStyle.Render(f.Prompt) + "\n\n")
 	b.WriteString("▸ " + m.textInput.View() + "\n")
-	b.WriteString(dimStyle.Render(fmt.Sprintf("  (Range: %d to %d, default: %s)", minVal, maxVal, defaultVal)) + "\n")
+	if f.Kind == config.KindPattern {
+		b.WriteString(dimStyle.Render("  (A regular expression, matched anywhere in the name)") + "\n")
+	} else {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  (Range: %s, default: %s)", f.Range(), f.Default())) + "\n")
+	}
 	return b.String()
 }
 
 func (m Model) renderConfirm() string {
 	var b strings.Builder
-	b.WriteString(normalStyle.Render("Review your configuration:") + "\n\n")
-
-	cfg := m.config
-	b.WriteString(dimStyle.Render("  Shape:     ") + normalStyle.Render(cfg.Crosshair.Shape) + "\n")
-	b.WriteString(dimStyle.Render("  Color:     ") + normalStyle.Render(cfg.Crosshair.Color) + "\n")
-	b.WriteString(dimStyle.Render("  Size:      ") + normalStyle.Render(fmt.Sprintf("%d px", cfg.Crosshair.Size)) + "\n")
-	// Only show thickness and gap for cross/cross-dot shapes
-	if m.shapeNeedsThicknessAndGap() {
-		b.WriteString(dimStyle.Render("  Thickness: ") + normalStyle.Render(fmt.Sprintf("%d px", cfg.Crosshair.Thickness)) + "\n")
-		b.WriteString(dimStyle.Render("  Gap:       ") + normalStyle.Render(fmt.Sprintf("%d px", cfg.Crosshair.Gap)) + "\n")
-	}
-	if cfg.Crosshair.OutlineThickness > 0 {
-		b.WriteString(dimStyle.Render("  Outline:   ") + normalStyle.Render(fmt.Sprintf("%d px (%s)", cfg.Crosshair.OutlineThickness, cfg.Crosshair.OutlineColor)) + "\n")
-	}
-	if cfg.Position.Monitor == -1 {
-		b.WriteString(dimStyle.Render("  Monitor:   ") + normalStyle.Render("Primary (auto)") + "\n")
+	if m.original != nil {
+		b.WriteString(normalStyle.Render("Review your changes:") + "\n\n")
 	} else {
-		b.WriteString(dimStyle.Render("  Monitor:   ") + normalStyle.Render(fmt.Sprintf("%d", cfg.Position.Monitor)) + "\n")
+		b.WriteString(normalStyle.Render("Review your configuration:") + "\n\n")
 	}
-	if cfg.Position.OffsetX != 0 || cfg.Position.OffsetY != 0 {
-		b.WriteString(dimStyle.Render("  Offset:    ") + normalStyle.Render(fmt.Sprintf("(%d, %d)", cfg.Position.OffsetX, cfg.Position.OffsetY)) + "\n")
+
+	var before map[string]string
+	if m.original != nil {
+		before = make(map[string]string)
+		for _, row := range summaryRows(m.original) {
+			before[row.label] = row.value
+		}
+	}
+
+	changed := 0
+	for _, row := range m.visibleSummaryRows() {
+		label := dimStyle.Render(fmt.Sprintf("  %-15s", row.label+":"))
+		old, ok := before[row.label]
+		if before == nil || !ok || old == row.value {
+			b.WriteString(label + normalStyle.Render(row.value) + "\n")
+			continue
+		}
+		changed++
+		b.WriteString(label + dimStyle.Render(old) + normalStyle.Render(" → ") + selectedStyle.Render(row.value) + "\n")
+	}
+
+	if m.original != nil && changed == 0 {
+		b.WriteString("\n" + dimStyle.Render("  No changes from the saved configuration.") + "\n")
 	}
 
 	b.WriteString("\n")
-	options := []string{"Save configuration", "Start over", "Cancel"}
-	for i, opt := range options {
+	for i, opt := range confirmOptions {
 		cursor := "  "
 		style := normalStyle
 		if i == m.cursor {
@@ -374,6 +661,71 @@ func (m Model) renderConfirm() string {
 	return b.String()
 }
 
+// summaryRow is one labeled value on the confirm screen.
+type summaryRow struct {
+	key   string
+	label string
+	value string
+}
+
+// summaryRows describes every setting the wizard edits in cfg for review
+// and diffing.
+func summaryRows(cfg *config.Config) []summaryRow {
+	fields := wizardFields()
+	rows := make([]summaryRow, len(fields))
+	for i, f := range fields {
+		rows[i] = summaryRow{key: f.Key, label: f.Label, value: displayValue(f, cfg)}
+	}
+	return rows
+}
+
+// visibleSummaryRows returns the summary rows that apply to the current shape.
+func (m Model) visibleSummaryRows() []summaryRow {
+	var rows []summaryRow
+	for _, row := range summaryRows(m.config) {
+		if fieldApplies(m.config, row.key) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// displayValue formats a field's value for the review and jump screens.
+func displayValue(f config.Field, cfg *config.Config) string {
+	switch {
+	case f.Key == "monitor" && f.Int(cfg) == -1:
+		return "Primary (auto)"
+	case f.Key == "outline_thickness" && f.Int(cfg) == 0:
+		return "Off"
+	case f.Kind == config.KindPattern && f.Value(cfg) == "":
+		return "Any"
+	case f.Unit != "":
+		return f.Value(cfg) + " " + f.Unit
+	default:
+		return f.Value(cfg)
+	}
+}
+
+// renderJumpMenu lists every field that applies to the current shape with
+// its value, so a single field can be edited without walking every step.
+func (m Model) renderJumpMenu() string {
+	var b strings.Builder
+	b.WriteString(normalStyle.Render("Jump to field:") + "\n\n")
+
+	for i, s := range m.jumpTargets() {
+		cursor := "  "
+		style := normalStyle
+		if i == m.cursor {
+			cursor = "▸ "
+			style = selectedStyle
+		}
+		f := field(s)
+		b.WriteString(cursor + style.Render(fmt.Sprintf("%-15s", f.Label)) + dimStyle.Render(displayValue(f, m.config)) + "\n")
+	}
+
+	return b.String()
+}
+
 func (m Model) renderStartPrompt() string {
 	var b strings.Builder
 	b.WriteString(successStyle.Render("✓ Configuration saved!") + "\n\n")
@@ -395,202 +747,136 @@ func (m Model) renderStartPrompt() string {
 
 func (m Model) maxCursor() int {
 	switch m.step {
-	case stepShape:
-		return len(shapeOptions) - 1
+	case stepShape, stepAnchor, stepVisibility:
+		return len(field(m.step).Choices) - 1
 	case stepColor, stepOutlineColor:
-		return len(colorPresets) - 1
-	case stepOutline:
-		return 1
+		return len(m.colorOptions()) - 1
 	case stepMonitor:
 		return len(m.monitors)
 	case stepConfirm:
-		return 2
+		return len(confirmOptions) - 1
 	case stepStartPrompt:
 		return 1
+	case stepJump:
+		return len(m.jumpTargets()) - 1
 	default:
 		return 0
 	}
 }
 
+// quitHelp names the key that quits on the current step.
+func (m Model) quitHelp() string {
+	if m.isTextInputStep() {
+		return "ctrl+c quit"
+	}
+	return "q quit"
+}
+
+// visionHelp returns the color vision hint, which text steps leave out
+// because ctrl+v pastes there.
+func (m Model) visionHelp() string {
+	if m.isTextInputStep() {
+		return ""
+	}
+	return "ctrl+v color vision • "
+}
+
 func (m Model) isTextInputStep() bool {
 	switch m.step {
-	case stepSize, stepThickness, stepGap, stepOffsetX, stepOffsetY:
+	case stepSize, stepThickness, stepGap, stepOutline, stepOffsetX, stepOffsetY, stepWindowClass, stepWindowTitle:
 		return true
 	case stepColor, stepOutlineColor:
-		return m.cursor == len(colorPresets)-1
+		return !m.picking && m.selectedColorOption().kind == optionCustom
 	default:
 		return false
 	}
 }
 
-// shapeNeedsThicknessAndGap returns true if the current shape needs thickness and gap settings
-func (m Model) shapeNeedsThicknessAndGap() bool {
-	shape := m.config.Crosshair.Shape
+// isColorStep reports whether the current step selects a color.
+func (m Model) isColorStep() bool {
+	return m.step == stepColor || m.step == stepOutlineColor
+}
+
+// shapeNeedsThicknessAndGap returns true if shape is drawn with arms
+func shapeNeedsThicknessAndGap(shape string) bool {
 	return shape == "cross" || shape == "cross-dot"
 }
 
 func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 	switch m.step {
-	case stepShape:
-		m.config.Crosshair.Shape = shapeOptions[m.cursor]
-		m.step = stepColor
-		m.cursor = 0
-
-	case stepColor:
-		if m.cursor == len(colorPresets)-1 {
-			if !m.textInput.Focused() {
-				m.textInput.SetValue("")
-				m.textInput.Focus()
-				return m, textinput.Blink
-			}
-			color := m.textInput.Value()
-			if !strings.HasPrefix(color, "#") {
-				color = "#" + color
-			}
-			if _, err := config.ParseColor(color); err != nil {
-				m.err = fmt.Errorf("invalid color format")
-				return m, nil
-			}
-			m.config.Crosshair.Color = color
-			m.textInput.Blur()
-		} else {
-			m.config.Crosshair.Color = colorPresets[m.cursor].value
-		}
-		m.step = stepSize
-		m.cursor = 0
-		m.textInput.SetValue("10")
-		m.textInput.Focus()
-		m.err = nil
-
-	case stepSize:
-		val, err := strconv.Atoi(m.textInput.Value())
-		if err != nil || val < 1 || val > 100 {
-			m.err = fmt.Errorf("enter a number between 1 and 100")
-			return m, nil
-		}
-		m.config.Crosshair.Size = val
-		// Skip thickness and gap for dot/circle shapes
-		if m.shapeNeedsThicknessAndGap() {
-			m.step = stepThickness
-			m.textInput.SetValue("2")
-		} else {
-			m.step = stepOutline
-			m.cursor = 0
-			m.textInput.Blur()
-		}
-		m.err = nil
-
-	case stepThickness:
-		val, err := strconv.Atoi(m.textInput.Value())
-		if err != nil || val < 1 || val > 20 {
-			m.err = fmt.Errorf("enter a number between 1 and 20")
+	case stepShape, stepAnchor, stepVisibility:
+		if err := field(m.step).Set(m.config, field(m.step).Choices[m.cursor]); err != nil {
+			m.err = err
 			return m, nil
 		}
-		m.config.Crosshair.Thickness = val
-		m.step = stepGap
-		m.textInput.SetValue("0")
-		m.err = nil
+		return m.advance(m.nextStep())
 
-	case stepGap:
-		val, err := strconv.Atoi(m.textInput.Value())
-		if err != nil || val < 0 || val > 50 {
-			m.err = fmt.Errorf("enter a number between 0 and 50")
+	case stepColor, stepOutlineColor:
+		switch m.selectedColorOption().kind {
+		case optionPicker:
+			m.picker = newColorPicker(field(m.step).Value(m.config))
+			m.picking = true
 			return m, nil
-		}
-		m.config.Crosshair.Gap = val
-		m.step = stepOutline
-		m.cursor = 0
-		m.textInput.Blur()
-		m.err = nil
-
-	case stepOutline:
-		if m.cursor == 0 {
-			m.config.Crosshair.OutlineThickness = 0
-			m.step = stepMonitor
-		} else {
-			m.config.Crosshair.OutlineThickness = 1
-			m.step = stepOutlineColor
-		}
-		m.cursor = 0
-		m.err = nil
-
-	case stepOutlineColor:
-		if m.cursor == len(colorPresets)-1 {
+		case optionCustom:
 			if !m.textInput.Focused() {
 				m.textInput.SetValue("")
 				m.textInput.Focus()
 				return m, textinput.Blink
 			}
-			color := m.textInput.Value()
-			if !strings.HasPrefix(color, "#") {
-				color = "#" + color
-			}
-			if _, err := config.ParseColor(color); err != nil {
-				m.err = fmt.Errorf("invalid color format")
-				return m, nil
-			}
-			m.config.Crosshair.OutlineColor = color
-			m.textInput.Blur()
-		} else {
-			m.config.Crosshair.OutlineColor = colorPresets[m.cursor].value
-		}
-		m.step = stepMonitor
-		m.cursor = 0
-		m.err = nil
-
-	case stepMonitor:
-		if m.cursor == 0 {
-			m.config.Position.Monitor = -1
-		} else {
-			m.config.Position.Monitor = m.cursor - 1
 		}
-		m.step = stepOffsetX
-		m.textInput.SetValue("0")
-		m.textInput.Focus()
-		m.err = nil
-
-	case stepOffsetX:
-		val, err := strconv.Atoi(m.textInput.Value())
-		if err != nil || val < -500 || val > 500 {
-			m.err = fmt.Errorf("enter a number between -500 and 500")
+		if err := field(m.step).Set(m.config, m.selectedColor()); err != nil {
+			m.err = err
 			return m, nil
 		}
-		m.config.Position.OffsetX = val
-		m.step = stepOffsetY
-		m.textInput.SetValue("0")
-		m.err = nil
+		return m.advance(m.nextStep())
 
-	case stepOffsetY:
-		val, err := strconv.Atoi(m.textInput.Value())
-		if err != nil || val < -500 || val > 500 {
-			m.err = fmt.Errorf("enter a number between -500 and 500")
+	case stepSize, stepThickness, stepGap, stepOutline, stepOffsetX, stepOffsetY, stepWindowClass, stepWindowTitle:
+		if err := field(m.step).Set(m.config, m.textInput.Value()); err != nil {
+			m.err = err
 			return m, nil
 		}
-		m.config.Position.OffsetY = val
-		m.step = stepConfirm
-		m.cursor = 0
-		m.textInput.Blur()
-		m.err = nil
+		return m.advance(m.nextStep())
+
+	case stepMonitor:
+		m.config.Position.Monitor = m.cursor - 1
+		return m.advance(m.nextStep())
 
 	case stepConfirm:
-		switch m.cursor {
-		case 0:
+		switch confirmOptions[m.cursor] {
+		case confirmSave:
+			if err := m.config.Validate(); err != nil {
+				m.err = err
+				return m, nil
+			}
 			if err := config.Save(m.configPath, m.config); err != nil {
 				m.err = err
 				return m, nil
 			}
 			m.saved = true
-			m.step = stepStartPrompt
-			m.cursor = 0
-		case 1:
-			m.config = config.Default()
-			m.step = stepShape
-			m.cursor = 0
-		case 2:
+			m.rememberColors()
+			if m.formMode {
+				m.formMode = false
+				m, cmd := m.enterStep(stepStartPrompt)
+				return m, tea.Batch(cmd, formScreen(false))
+			}
+			return m.enterStep(stepStartPrompt)
+		case confirmEdit:
+			if m.formMode {
+				return m.enterStep(stepForm)
+			}
+			return m.openJumpMenu()
+		case confirmStartOver:
+			m.config = m.startOverConfig()
+			return m.enterStep(stepShape)
+		case confirmCancel:
 			m.quitting = true
 			return m, tea.Quit
 		}
 
+	case stepJump:
+		m.jumped = true
+		return m.enterStep(m.jumpTargets()[m.cursor])
+
 	case stepStartPrompt:
 		if m.cursor == 0 {
 			m.startCrosshair = true
@@ -602,6 +888,142 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// advance moves on after a field has been accepted. When the field was
+// reached from the jump menu it returns to the confirm screen instead, and
+// when it was opened from the form view it returns to the form, unless the
+// change enabled a follow-up field such as the outline color or the window
+// to anchor to.
+func (m Model) advance(next step) (Model, tea.Cmd) {
+	followUp := next == stepOutlineColor || next == stepWindowClass
+	if m.formMode && !followUp {
+		return m.enterStep(stepForm)
+	}
+	if m.jumped && !followUp {
+		m.jumped = false
+		return m.enterStep(stepConfirm)
+	}
+	return m.enterStep(next)
+}
+
+// openJumpMenu shows the jump-to-field menu with the current field selected.
+func (m Model) openJumpMenu() (Model, tea.Cmd) {
+	current := m.step
+	m, cmd := m.enterStep(stepJump)
+	for i, s := range m.jumpTargets() {
+		if s == current {
+			m.cursor = i
+		}
+	}
+	return m, cmd
+}
+
+// enterStep switches to step s and pre-selects or pre-fills it from the
+// current configuration, so editing an existing file keeps its values.
+func (m Model) enterStep(s step) (Model, tea.Cmd) {
+	m.step = s
+	m.cursor = 0
+	m.err = nil
+	m.picking = false
+	m.textInput.Blur()
+
+	cfg := m.config
+	focusText := func(val string) {
+		m.textInput.SetValue(val)
+		m.textInput.Focus()
+	}
+	selectColor := func(color string) {
+		m.cursor = m.colorOptionIndex(color)
+		if m.selectedColorOption().kind == optionCustom {
+			m.textInput.SetValue(color)
+			m.textInput.Focus()
+		}
+	}
+
+	switch s {
+	case stepShape, stepAnchor, stepVisibility:
+		m.cursor = max(slices.Index(field(s).Choices, field(s).Value(cfg)), 0)
+	case stepColor, stepOutlineColor:
+		selectColor(field(s).Value(cfg))
+	case stepSize, stepThickness, stepGap, stepOutline, stepOffsetX, stepOffsetY, stepWindowClass, stepWindowTitle:
+		m.textInput.CharLimit, m.textInput.Width = 20, 20
+		if field(s).Kind == config.KindPattern {
+			m.textInput.CharLimit, m.textInput.Width = 200, 40
+		}
+		focusText(field(s).Value(cfg))
+	case stepMonitor:
+		m.cursor = min(max(cfg.Position.Monitor+1, 0), m.maxCursor())
+	}
+
+	if m.textInput.Focused() {
+		return m, textinput.Blink
+	}
+	return m, nil
+}
+
+// nextStep returns the step after the current one, skipping steps that
+// do not apply to the chosen shape or outline setting.
+func (m Model) nextStep() step {
+	next := m.step + 1
+	for next < stepConfirm && !m.stepApplies(next) {
+		next++
+	}
+	return next
+}
+
+// previousStep returns the step before the current one, skipping steps that
+// do not apply to the chosen shape or outline setting.
+func (m Model) previousStep() step {
+	prev := m.step - 1
+	for prev > stepShape && !m.stepApplies(prev) {
+		prev--
+	}
+	return prev
+}
+
+// stepApplies reports whether a field step is relevant to the current configuration.
+func (m Model) stepApplies(s step) bool {
+	return fieldApplies(m.config, stepFields[s])
+}
+
+// fieldApplies reports whether the field with the given key has any effect
+// with the rest of cfg: thickness and gap only matter for cross shapes, the
+// outline color only when an outline is drawn, and the window patterns only
+// when the crosshair is anchored to a window.
+func fieldApplies(cfg *config.Config, key string) bool {
+	switch key {
+	case "thickness", "gap":
+		return shapeNeedsThicknessAndGap(cfg.Crosshair.Shape)
+	case "outline_color":
+		return cfg.Crosshair.OutlineThickness > 0
+	case "window_class", "window_title":
+		return cfg.Position.Anchor == config.AnchorWindow
+	default:
+		return true
+	}
+}
+
+// jumpTargets returns the field steps that can be reached from the jump menu.
+func (m Model) jumpTargets() []step {
+	var targets []step
+	for s := stepShape; s < stepConfirm; s++ {
+		if m.stepApplies(s) {
+			targets = append(targets, s)
+		}
+	}
+	return targets
+}
+
+// rememberColors adds the saved colors to the recently used palette.
+// The palette is a convenience, so failing to store it is not an error.
+func (m *Model) rememberColors() {
+	colors := []string{m.config.Crosshair.Color}
+	if m.config.Crosshair.OutlineThickness > 0 {
+		colors = append(colors, m.config.Crosshair.OutlineColor)
+	}
+	m.recent = addRecentColors(m.recent, colors...)
+	_ = saveRecentColors(recentColorsPath(m.configPath), m.recent)
+}
+
 // GetConfig returns the configured settings
 func (m Model) GetConfig() *config.Config {
 	return m.config